@@ -2,34 +2,57 @@
 package app
 
 import (
-	"fmt"
 	"github.com/sinaw369/Hermes/cmd/command"
 	"github.com/sinaw369/Hermes/internal/config"
 	"github.com/spf13/cobra"
 	"log"
+	"os"
 )
 
 // main is the entry point of the application.
 func Run() {
 	const description = "Hermes"
-	root := &cobra.Command{Short: description}
+	root := &cobra.Command{Short: description, SilenceUsage: true, SilenceErrors: true}
 
 	SyncCmd := command.NewSyncCmd()
 	diffCmd := command.NewDiffCmd()
+	pruneCmd := command.NewPruneCmd()
+	stashesCmd := command.NewStashesCmd()
+	unlockCmd := command.NewUnlockCmd()
+	doctorCmd := command.NewDoctorCmd()
+	initCmd := command.NewInitCmd()
+	promoteCmd := command.NewPromoteCmd()
+	releaseNotesCmd := command.NewReleaseNotesCmd()
 	var HermesCmd command.HermesCmd
 
-	cfg, err := config.Load()
-	if err != nil {
-		return
+	// The configuration is loaded once the --profile flag is parsed; commands only read it when they run.
+	cfg := &config.Config{}
+	var profile string
+	root.PersistentFlags().StringVar(&profile, "profile", "", "profile of "+config.FilePath()+" to use (defaults to HERMES_PROFILE or default_profile)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		loaded, err := config.Load(profile)
+		if err != nil {
+			return err
+		}
+		*cfg = *loaded
+		return nil
 	}
 
 	root.AddCommand(
 		SyncCmd.Command(cfg),
 		HermesCmd.Command(cfg),
 		diffCmd.Command(cfg),
+		pruneCmd.Command(cfg),
+		stashesCmd.Command(cfg),
+		unlockCmd.Command(cfg),
+		doctorCmd.Command(cfg),
+		initCmd.Command(cfg),
+		promoteCmd.Command(cfg),
+		releaseNotesCmd.Command(cfg),
 	)
 
-	if err := root.Execute(); err != nil {
-		log.Fatalf(fmt.Sprintf("failed to execute root command: \n%v", err))
+	if cmd, err := root.ExecuteC(); err != nil {
+		log.Printf("failed to execute root command: \n%v", err)
+		os.Exit(command.ErrorExitCode(cmd))
 	}
 }
@@ -6,12 +6,13 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sinaw369/Hermes/internal/client"
 	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
 	"github.com/sinaw369/Hermes/internal/logWriter"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
-	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -21,9 +22,22 @@ type DiffCmd struct {
 	branchFrom    string
 	branchTo      string
 	onlyWithDiff  bool
+	fetch         bool
+	format        string
+	exitCode      bool
+	differs       bool // some repository has differences
+	failed        bool // the diff of some repository could not be read
 	contextValues map[string]string
 }
 
+// Output formats of the diff command.
+const (
+	diffFormatText     = "text"
+	diffFormatJSON     = "json"
+	diffFormatCSV      = "csv"
+	diffFormatMarkdown = "markdown"
+)
+
 func NewDiffCmd() *DiffCmd {
 	return &DiffCmd{
 		contextValues: make(map[string]string),
@@ -34,12 +48,26 @@ func (sc *DiffCmd) Command(cfg *config.Config) *cobra.Command {
 	diffCmd := &cobra.Command{
 		Use:   "diff",
 		Short: "Show diff summary between two branches for one or more repositories",
-		Run: func(cmd *cobra.Command, args []string) {
-			err := sc.startApp(cfg)
-			if err != nil {
-				log.Println("err is :", err)
-				return
+		Long: "Lists the commits of --branch-to that are not on --branch-from in every repository. --format " +
+			"json, csv or markdown prints them for scripts and reports instead of the boxes sized to the " +
+			"terminal. With --exit-code the command exits with status 1 if any repository has differences " +
+			"and 2 if the diff of a repository could not be read or the command failed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("fetch") {
+				sc.fetch = cfg.DiffFetch
+			}
+			if err := sc.startApp(cfg); err != nil {
+				return err
 			}
+			if sc.exitCode {
+				switch {
+				case sc.failed:
+					os.Exit(2)
+				case sc.differs:
+					os.Exit(1)
+				}
+			}
+			return nil
 		},
 	}
 
@@ -49,9 +77,25 @@ func (sc *DiffCmd) Command(cfg *config.Config) *cobra.Command {
 	diffCmd.Flags().StringVar(&sc.branchFrom, "branch-from", "", "Source branch for diff (e.g., develop)")
 	diffCmd.Flags().StringVar(&sc.branchTo, "branch-to", "", "Target branch for diff (e.g., production)")
 	diffCmd.Flags().BoolVar(&sc.onlyWithDiff, "only-with-diff", false, "Show only projects that have differences between branches")
+	diffCmd.Flags().BoolVar(&sc.fetch, "fetch", false, "Fetch the two branches from origin in every repository first (defaults to DIFF_FETCH)")
+	diffCmd.Flags().StringVar(&sc.format, "format", diffFormatText, "Output format: text, json, csv or markdown")
+	diffCmd.Flags().BoolVar(&sc.exitCode, "exit-code", false, "Exit with status 1 if any repository has differences (2 if a diff could not be read)")
 
 	return diffCmd
 }
+
+// ErrorExitCode returns the exit status for a command that failed. With "hermes diff --exit-code"
+// it is 2, as 1 means that there are differences; otherwise it is 1. The flag is also looked for in
+// the arguments, as it is not parsed when an earlier flag is unknown.
+func ErrorExitCode(cmd *cobra.Command) int {
+	if cmd != nil {
+		if flag := cmd.Flags().Lookup("exit-code"); flag != nil && (flag.Value.String() == "true" || slices.Contains(os.Args, "--exit-code")) {
+			return 2
+		}
+	}
+	return 1
+}
+
 func (sc *DiffCmd) FetchFromEnvironment(cfg *config.Config) {
 	if sc.baseDir == "" {
 		sc.baseDir = cfg.WorkingDir
@@ -63,9 +107,41 @@ func (sc *DiffCmd) FetchFromEnvironment(cfg *config.Config) {
 		sc.branchFrom = cfg.DiffBranchFrom
 	}
 }
+
+// nameDir returns the directory repository names of the json, csv and markdown formats are relative
+// to: the part of --basedir before any glob, or the parent of the repository when --basedir is a
+// single repository. The text format names them relative to WORKING_DIR.
+func (sc *DiffCmd) nameDir() string {
+	dir := sc.baseDir
+	if !strings.ContainsAny(dir, "*?[]") && sc.pathPattern == "" {
+		return filepath.Dir(dir)
+	}
+	for strings.ContainsAny(dir, "*?[]") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
 func (sc *DiffCmd) startApp(cfg *config.Config) error {
 	sc.FetchFromEnvironment(cfg)
-	logger := logWriter.NewLogger(os.Stdout, false, false)
+	if sc.baseDir == "" {
+		return fmt.Errorf("no --basedir given and %v", cfg.Require(config.KeyWorkingDir))
+	}
+	if sc.branchFrom == "" || sc.branchTo == "" {
+		return fmt.Errorf("no --branch-from/--branch-to given and %v", cfg.Require(config.KeyDiffBranchFrom, config.KeyDiffBranchTo))
+	}
+	switch sc.format {
+	case diffFormatText, diffFormatJSON, diffFormatCSV, diffFormatMarkdown:
+	default:
+		return fmt.Errorf("unknown --format %q (expected text, json, csv or markdown)", sc.format)
+	}
+	// The output of the fetches is not shown; their errors are reported with each repository.
+	sc.contextValues[constant.SilentMode] = constant.ContextValueYES
+	if sc.fetch && cfg.CloneProtocol == constant.CloneProtocolHTTPS {
+		if err := cfg.Require(config.KeyGitlabToken); err != nil {
+			return err
+		}
+	}
 	gitClient, err := client.NewCLIGitClient(context.Background(), sc.contextValues, cfg)
 	if err != nil {
 		return err
@@ -119,6 +195,38 @@ func (sc *DiffCmd) startApp(cfg *config.Config) error {
 		repos = []string{sc.baseDir}
 	}
 
+	// Update the compared branches of all repositories at once.
+	var fetchErrs map[string]error
+	if sc.fetch {
+		fetchErrs = gitClient.FetchRefsConcurrently(repos, sc.branchFrom, sc.branchTo)
+	}
+	if sc.format == diffFormatText {
+		sc.printText(cfg, gitClient, repos, fetchErrs)
+		return nil
+	}
+
+	var results []diffResult
+	for _, repoPath := range repos {
+		result := sc.diffRepository(repoPath, fetchErrs[repoPath])
+		if !sc.onlyWithDiff || result.CommitCount > 0 || result.Error != "" {
+			results = append(results, result)
+		}
+	}
+	switch sc.format {
+	case diffFormatJSON:
+		return printDiffJSON(results, sc.differs)
+	case diffFormatCSV:
+		return printDiffCSV(results)
+	default:
+		printDiffMarkdown(results)
+		return nil
+	}
+}
+
+// printText prints the diff of every repository in a box sized to the terminal.
+func (sc *DiffCmd) printText(cfg *config.Config, gitClient *client.GitlabClient, repos []string, fetchErrs map[string]error) {
+	logger := logWriter.NewLogger(os.Stdout, false, false)
+
 	// Dynamically obtain the terminal width.
 	width, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
@@ -144,14 +252,24 @@ func (sc *DiffCmd) startApp(cfg *config.Config) error {
 
 		// Format header using Lip Gloss.
 		headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("85"))
-		header := headerStyle.Render("Repository: " + repoName)
+		ageStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+		header := headerStyle.Render("Repository: "+repoName) + "  " + ageStyle.Render("("+client.RefsAge(repoPath)+")")
+		if err := fetchErrs[repoPath]; err != nil {
+			header += "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("fetch failed: "+err.Error())
+		}
 
 		// Fetch diff summary from the Git client.
 		diff, hasChange, err := gitClient.FetchDiffCLI(repoPath, sc.branchFrom, sc.branchTo)
 		if err != nil {
+			sc.failed = true
+			if fetchErr := fetchErrs[repoPath]; fetchErr != nil {
+				logger.RedString("Error fetching %s or %s for %s: %v\n", sc.branchFrom, sc.branchTo, repoName, fetchErr)
+				continue
+			}
 			logger.RedString("Error fetching diff for %s: it seems branch %s or %s does not exist\n", repoName, sc.branchFrom, sc.branchTo)
 			continue
 		}
+		sc.differs = sc.differs || hasChange
 
 		// Only print the diff if either we want all or we want only repos with diffs and there is a diff.
 		if !sc.onlyWithDiff || (sc.onlyWithDiff && hasChange) {
@@ -161,5 +279,4 @@ func (sc *DiffCmd) startApp(cfg *config.Config) error {
 			fmt.Println()
 		}
 	}
-	return nil
 }
@@ -0,0 +1,113 @@
+// Package command cmd/command/diffformat.go
+package command
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// diffResult is the diff of one repository in the json, csv and markdown formats.
+type diffResult struct {
+	Repository  string          `json:"repository"`
+	Path        string          `json:"path"`
+	BranchFrom  string          `json:"branch_from"`
+	BranchTo    string          `json:"branch_to"`
+	RefsFetched *time.Time      `json:"refs_fetched,omitempty"`
+	CommitCount int             `json:"commit_count"`
+	Commits     []client.Commit `json:"commits"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// diffOutput is the document printed by --format json.
+type diffOutput struct {
+	Differences  bool         `json:"differences"`
+	Repositories []diffResult `json:"repositories"`
+}
+
+// diffRepository reads the commits of the repository between the two branches.
+func (sc *DiffCmd) diffRepository(repoPath string, fetchErr error) diffResult {
+	result := diffResult{
+		Repository: repoName(sc.nameDir(), repoPath),
+		Path:       repoPath,
+		BranchFrom: sc.branchFrom,
+		BranchTo:   sc.branchTo,
+		Commits:    []client.Commit{},
+	}
+	if fetched, ok := client.LastFetch(repoPath); ok {
+		result.RefsFetched = &fetched
+	}
+	commits, err := client.DiffCommits(repoPath, sc.branchFrom, sc.branchTo)
+	switch {
+	case err != nil && fetchErr != nil:
+		result.Error = "fetch failed: " + fetchErr.Error()
+	case err != nil:
+		result.Error = fmt.Sprintf("branch %s or %s does not exist", sc.branchFrom, sc.branchTo)
+	case commits != nil:
+		result.Commits = commits
+	}
+	result.CommitCount = len(result.Commits)
+	sc.failed = sc.failed || result.Error != ""
+	sc.differs = sc.differs || result.CommitCount > 0
+	return result
+}
+
+// printDiffJSON prints the results as one JSON document.
+func printDiffJSON(results []diffResult, differences bool) error {
+	if results == nil {
+		results = []diffResult{}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diffOutput{Differences: differences, Repositories: results})
+}
+
+// printDiffCSV prints a row per commit, and a row without commit for repositories without
+// differences or with an error.
+func printDiffCSV(results []diffResult) error {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Write([]string{"repository", "path", "branch_from", "branch_to", "commit_count", "hash", "subject", "author", "date", "error"})
+	for _, r := range results {
+		row := []string{r.Repository, r.Path, r.BranchFrom, r.BranchTo, strconv.Itoa(r.CommitCount)}
+		if len(r.Commits) == 0 {
+			writer.Write(append(row, "", "", "", "", r.Error))
+		}
+		for _, commit := range r.Commits {
+			writer.Write(append(row, commit.Hash, commit.Subject, commit.Author, commit.Date.Format(time.RFC3339), r.Error))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// printDiffMarkdown prints a section per repository with a table of its commits.
+func printDiffMarkdown(results []diffResult) {
+	for i, r := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("## %s (%s..%s)\n\n", r.Repository, r.BranchFrom, r.BranchTo)
+		switch {
+		case r.Error != "":
+			fmt.Printf("Error: %s\n", r.Error)
+		case r.CommitCount == 0:
+			fmt.Printf("No differences between %s and %s.\n", r.BranchFrom, r.BranchTo)
+		default:
+			fmt.Printf("%d commit(s)\n\n| Commit | Subject | Author | Date |\n| --- | --- | --- | --- |\n", r.CommitCount)
+			for _, commit := range r.Commits {
+				fmt.Printf("| `%s` | %s | %s | %s |\n", commit.Hash[:min(len(commit.Hash), 8)],
+					markdownCell(commit.Subject), markdownCell(commit.Author), commit.Date.Format("2006-01-02 15:04"))
+			}
+		}
+	}
+}
+
+// markdownCell escapes the text for a cell of a Markdown table.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,89 @@
+// Package command cmd/command/doctor.go
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type DoctorCmd struct {
+	json    bool
+	loadErr error
+}
+
+func NewDoctorCmd() *DoctorCmd {
+	return &DoctorCmd{}
+}
+
+// doctorReport is the JSON form of the doctor checklist.
+type doctorReport struct {
+	OK     bool           `json:"ok"`
+	Checks []client.Check `json:"checks"`
+}
+
+// doctorMarks prefix the checks in the text checklist.
+var doctorMarks = map[string]string{
+	client.CheckPass: "[ OK ]",
+	client.CheckWarn: "[WARN]",
+	client.CheckFail: "[FAIL]",
+	client.CheckSkip: "[SKIP]",
+}
+
+func (dc *DoctorCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration, GitLab access, git, SSH and the working directory",
+		Long: "Runs a checklist of the configuration and token resolution, reachability of GITLAB_BASE_URL, " +
+			"token validity and scopes, the git version, SSH access to GitLab, write access to WORKING_DIR " +
+			"and free disk space. Exits with status 1 if a check failed.",
+		// An invalid configuration is reported as a failed check instead of stopping the command.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			profile, _ := cmd.Flags().GetString("profile")
+			loaded, err := config.Load(profile)
+			if err != nil {
+				dc.loadErr = err
+				return nil
+			}
+			*cfg = *loaded
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			checks := client.Diagnose(cfg, dc.loadErr)
+			report := doctorReport{OK: true, Checks: checks}
+			for _, check := range checks {
+				if check.Status == client.CheckFail {
+					report.OK = false
+				}
+			}
+
+			if dc.json {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				}
+			} else {
+				for _, check := range checks {
+					fmt.Printf("%s %s", doctorMarks[check.Status], check.Name)
+					if check.Detail != "" {
+						fmt.Printf(": %s", check.Detail)
+					}
+					fmt.Println()
+					if check.Hint != "" {
+						fmt.Printf("       hint: %s\n", check.Hint)
+					}
+				}
+			}
+			if !report.OK {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&dc.json, "json", false, "print the checks as JSON")
+
+	return cmd
+}
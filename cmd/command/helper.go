@@ -0,0 +1,46 @@
+// Package command cmd/command/helper.go
+package command
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/state"
+	"github.com/spf13/cobra"
+	"path/filepath"
+)
+
+// resolveDir returns dir, or the configured working directory if dir is empty.
+// The result must be an absolute path.
+func resolveDir(cfg *config.Config, dir string) (string, error) {
+	if dir == "" {
+		if err := cfg.Require(config.KeyWorkingDir); err != nil {
+			return "", fmt.Errorf("no --dir given and %v", err)
+		}
+		dir = cfg.WorkingDir
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("dir should be full path: %s", dir)
+	}
+	return dir, nil
+}
+
+// applyPreset fills the flags that were not given on the command line from the preset named by
+// --preset, saved from a TUI form. fields maps flag names to the form's field labels.
+func applyPreset(cmd *cobra.Command, cfg *config.Config, form string, fields map[string]string) error {
+	name, _ := cmd.Flags().GetString("preset")
+	if name == "" {
+		return nil
+	}
+	preset, err := state.NewStore(cfg.StateDir).LoadPreset(form, name)
+	if err != nil {
+		return err
+	}
+	for flag, label := range fields {
+		if value := preset.Values[label]; value != "" && !cmd.Flags().Changed(flag) {
+			if err := cmd.Flags().Set(flag, value); err != nil {
+				return fmt.Errorf("preset %q: %s: %v", name, label, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package command cmd/command/init.go
+package command
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/form/initScreen"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"github.com/spf13/cobra"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+type InitCmd struct{}
+
+func NewInitCmd() *InitCmd {
+	return &InitCmd{}
+}
+
+func (ic *InitCmd) Command(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
+		Short: "Create a profile in the config file with an interactive wizard",
+		Long: "Asks for the GitLab instance and an access token, checks the token, lets you pick the groups " +
+			"to sync and the working directory and diff branches, and writes them as a profile to " +
+			config.FilePath() + ". The token is stored in a separate file that only you can read.",
+		// The wizard is how a broken or missing configuration gets fixed, so it must not fail to load.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			profile, _ := cmd.Flags().GetString("profile")
+			if loaded, err := config.Load(profile); err == nil {
+				*cfg = *loaded
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			path := config.FilePath()
+			if path == "" {
+				log.Println("cannot find the user config directory; set HERMES_CONFIG to the config file path")
+				return
+			}
+			profile, _ := cmd.Flags().GetString("profile")
+			if profile == "" {
+				profile = cfg.Profile
+			}
+			if profile == "" {
+				profile = "default"
+			}
+
+			verify := func(baseURL, token string) (string, []string, error) {
+				account, err := client.LookupAccount(cfg, baseURL, token)
+				if err != nil {
+					return "", nil, err
+				}
+				return account.Username, account.Groups, nil
+			}
+			// The forms log the values they collect, including the token, so their logger discards everything.
+			wizard := initScreen.NewModel(initScreen.Defaults{
+				Profile:    profile,
+				BaseURL:    cfg.GitlabBaseURL,
+				WorkingDir: cfg.WorkingDir,
+				BranchFrom: cfg.DiffBranchFrom,
+				BranchTo:   cfg.DifBranchTO,
+			}, verify, logWriter.NewLogger(io.Discard, false, true))
+
+			if _, err := tea.NewProgram(wizard).Run(); err != nil {
+				log.Fatalf("Error running the wizard: %v", err)
+			}
+			if wizard.Result == nil {
+				fmt.Println("Setup cancelled; nothing was written.")
+				return
+			}
+			if err := ic.write(path, wizard.Result); err != nil {
+				log.Println("err is :", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// write stores the token in its own file next to the config file and the rest in the profile.
+func (ic *InitCmd) write(path string, result *initScreen.Result) error {
+	tokenPath := filepath.Join(filepath.Dir(path), result.Profile+".token")
+	if err := config.SaveTokenFile(tokenPath, result.Token); err != nil {
+		return fmt.Errorf("writing token file: %v", err)
+	}
+
+	values := map[string]interface{}{
+		config.KeyGitlabBaseURL:   result.BaseURL,
+		config.KeyGitlabTokenFile: tokenPath,
+		config.KeyWorkingDir:      result.WorkingDir,
+		config.KeyDiffBranchFrom:  result.BranchFrom,
+		config.KeyDiffBranchTo:    result.BranchTo,
+	}
+	// "group/*" selects the projects of the group and its subgroups, both on GitLab and in the working directory.
+	include := make([]string, 0, len(result.Groups))
+	for _, group := range result.Groups {
+		include = append(include, group+"/*")
+	}
+	values[config.KeyInclude] = include
+	if err := config.SaveProfile(path, result.Profile, values); err != nil {
+		return fmt.Errorf("writing config file: %v", err)
+	}
+
+	fmt.Printf("Signed in to %s as @%s.\n", result.BaseURL, result.Username)
+	fmt.Printf("Wrote profile %q to %s; the token is in %s.\n", result.Profile, path, tokenPath)
+	if len(result.Groups) == 0 {
+		fmt.Println("No group selected: sync includes every project you can access.")
+	}
+	fmt.Printf("Run 'hermes --profile %s doctor' to check the setup, then 'hermes --profile %s sync'.\n", result.Profile, result.Profile)
+	return nil
+}
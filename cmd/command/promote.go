@@ -0,0 +1,166 @@
+// Package command cmd/command/promote.go
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type PromoteCmd struct {
+	from          string
+	to            string
+	title         string
+	fetch         bool
+	yes           bool
+	contextValues map[string]string
+}
+
+func NewPromoteCmd() *PromoteCmd {
+	return &PromoteCmd{
+		contextValues: make(map[string]string),
+	}
+}
+
+func (pc *PromoteCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Open a merge request in every repository where one branch is ahead of another",
+		Long: "Finds the repositories where origin/<from> has commits that origin/<to> lacks, the same " +
+			"detection as 'hermes diff', lists them with their commit counts and, once confirmed, opens a " +
+			"merge request from <from> into <to> in each of them with the commits in its description. " +
+			"An open merge request between the two branches is reused and its description updated.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := cfg.Require(config.KeyGitlabBaseURL, config.KeyGitlabToken); err != nil {
+				log.Println(err)
+				return
+			}
+			if !cmd.Flags().Changed("fetch") {
+				pc.fetch = cfg.DiffFetch
+			}
+			// The branches default to the ones of the diff view, which lists the commits of
+			// DIFF_BRANCH_TO missing from DIFF_BRANCH_FROM.
+			if pc.from == "" {
+				pc.from = cfg.DifBranchTO
+			}
+			if pc.to == "" {
+				pc.to = cfg.DiffBranchFrom
+			}
+			if pc.from == "" || pc.to == "" {
+				log.Printf("no --from/--to given and %v", cfg.Require(config.KeyDiffBranchTo, config.KeyDiffBranchFrom))
+				return
+			}
+			if pc.title == "" {
+				pc.title = fmt.Sprintf("Promote %s to %s", pc.from, pc.to)
+			}
+			dir, _ := cmd.Flags().GetString("dir")
+			dir, err := resolveDir(cfg, dir)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			pc.contextValues[constant.TargetDir] = dir
+			pc.contextValues[constant.ContextValueInclude], _ = cmd.Flags().GetString("include")
+			pc.contextValues[constant.ContextValueExclude], _ = cmd.Flags().GetString("exclude")
+			pc.contextValues[constant.SilentMode] = constant.ContextValueYES
+			if err := pc.promote(cfg, dir); err != nil {
+				log.Println("err is :", err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&pc.from, "from", "", "branch to promote, the source of the merge requests (defaults to DIFF_BRANCH_TO)")
+	cmd.Flags().StringVar(&pc.to, "to", "", "branch to promote to, the target of the merge requests (defaults to DIFF_BRANCH_FROM)")
+	cmd.Flags().StringVar(&pc.title, "title", "", "title of the merge requests (defaults to \"Promote <from> to <to>\")")
+	cmd.Flags().String("dir", "", "Directory containing the repositories and should be full path")
+	cmd.Flags().String("include", "", "include repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().String("exclude", "", "exclude repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().BoolVar(&pc.fetch, "fetch", false, "Fetch the two branches from origin in every repository first (defaults to DIFF_FETCH)")
+	cmd.Flags().BoolVarP(&pc.yes, "yes", "y", false, "open the merge requests without asking for confirmation")
+
+	return cmd
+}
+
+// promote lists the repositories with pending commits and opens their merge requests once confirmed.
+func (pc *PromoteCmd) promote(cfg *config.Config, dir string) error {
+	gitClient, err := client.NewCLIGitClient(context.Background(), pc.contextValues, cfg)
+	if err != nil {
+		return err
+	}
+	repos, err := gitClient.Repositories()
+	if err != nil {
+		return err
+	}
+	var fetchErrs map[string]error
+	if pc.fetch {
+		fetchErrs = gitClient.FetchRefsConcurrently(repos, pc.from, pc.to)
+	}
+
+	var ready []client.Promotion
+	for _, promotion := range client.PendingPromotions(repos, pc.from, pc.to) {
+		name := repoName(dir, promotion.Repository)
+		if promotion.Err != nil {
+			if fetchErr := fetchErrs[promotion.Repository]; fetchErr != nil {
+				fmt.Printf("%s: skipped, fetch failed: %v\n", name, fetchErr)
+			} else {
+				fmt.Printf("%s: skipped, branch %s or %s does not exist\n", name, pc.from, pc.to)
+			}
+			continue
+		}
+		fmt.Printf("%s: %d commit(s)\n", name, len(promotion.Commits))
+		ready = append(ready, promotion)
+	}
+	if len(ready) == 0 {
+		fmt.Printf("No repository has commits on %s that %s lacks.\n", pc.from, pc.to)
+		return nil
+	}
+	if !pc.yes && !confirm(fmt.Sprintf("Open merge requests from %s into %s in %d repositories?", pc.from, pc.to, len(ready))) {
+		fmt.Println("Nothing was opened.")
+		return nil
+	}
+
+	failed := 0
+	for _, promotion := range ready {
+		name := repoName(dir, promotion.Repository)
+		result, err := gitClient.Promote(promotion, pc.from, pc.to, pc.title)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: %v\n", name, err)
+			failed++
+		case result.Reused:
+			fmt.Printf("%s: updated open merge request !%d %s\n", name, result.IID, result.WebURL)
+		default:
+			fmt.Printf("%s: opened merge request !%d %s\n", name, result.IID, result.WebURL)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d merge requests could not be opened", failed, len(ready))
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal; anything but yes is no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// repoName returns the repository path relative to dir when possible.
+func repoName(dir, repoPath string) string {
+	if rel, err := filepath.Rel(dir, repoPath); err == nil {
+		return rel
+	}
+	return repoPath
+}
@@ -0,0 +1,56 @@
+// Package command cmd/command/prune.go
+package command
+
+import (
+	"context"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+type PruneCmd struct {
+	contextValues map[string]string
+}
+
+func NewPruneCmd() *PruneCmd {
+	return &PruneCmd{
+		contextValues: make(map[string]string),
+	}
+}
+
+func (pc *PruneCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Prune stale remote-tracking refs and merged or gone local branches",
+		Long: "Runs 'git fetch --all --prune' in every repository and deletes local branches that track a remote " +
+			"branch and are merged into the default branch or whose upstream is gone. Branches without an " +
+			"upstream and branches with unpushed commits are kept.",
+		Run: func(cmd *cobra.Command, args []string) {
+			pruneDir, _ := cmd.Flags().GetString("dir")
+			pruneDir, err := resolveDir(cfg, pruneDir)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			pc.contextValues[constant.TargetDir] = pruneDir
+			pc.contextValues[constant.ContextValueInclude], _ = cmd.Flags().GetString("include")
+			pc.contextValues[constant.ContextValueExclude], _ = cmd.Flags().GetString("exclude")
+			pc.contextValues[constant.ContextValueLockWait], _ = cmd.Flags().GetString("lock-wait")
+
+			gitClient, err := client.NewCLIGitClient(context.Background(), pc.contextValues, cfg)
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			gitClient.InitPruneFromDir()
+		},
+	}
+	cmd.Flags().String("dir", "", "Directory containing the repositories and should be full path")
+	cmd.Flags().String("include", "", "include repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().String("exclude", "", "exclude repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().String("lock-wait", "", "how long to wait for a locked workspace, e.g. 5m (defaults to LOCK_WAIT; 0 fails right away)")
+
+	return cmd
+}
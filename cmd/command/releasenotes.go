@@ -0,0 +1,128 @@
+// Package command cmd/command/releasenotes.go
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/releasenotes"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+type ReleaseNotesCmd struct {
+	from          string
+	to            string
+	combined      bool
+	fetch         bool
+	contextValues map[string]string
+}
+
+func NewReleaseNotesCmd() *ReleaseNotesCmd {
+	return &ReleaseNotesCmd{
+		contextValues: make(map[string]string),
+	}
+}
+
+func (rc *ReleaseNotesCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "release-notes",
+		Short: "Write Markdown release notes of the commits between two branches or tags",
+		Long: "Lists the commits of <to> that are not on <from> in every repository, where both are remote " +
+			"branches or tags, groups them into breaking changes, features, fixes and chores by their " +
+			"Conventional Commit subjects, links merge request (!123) and issue (#45) references of the commits and of the merge commits that merged them, and prints " +
+			"Markdown notes per repository, or one set of notes for all of them with --combined.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("fetch") {
+				rc.fetch = cfg.DiffFetch
+			}
+			if rc.from == "" {
+				rc.from = cfg.DiffBranchFrom
+			}
+			if rc.to == "" {
+				rc.to = cfg.DifBranchTO
+			}
+			if rc.from == "" || rc.to == "" {
+				log.Printf("no --from/--to given and %v", cfg.Require(config.KeyDiffBranchFrom, config.KeyDiffBranchTo))
+				return
+			}
+			if rc.fetch && cfg.CloneProtocol == constant.CloneProtocolHTTPS {
+				if err := cfg.Require(config.KeyGitlabToken); err != nil {
+					log.Println(err)
+					return
+				}
+			}
+			dir, _ := cmd.Flags().GetString("dir")
+			dir, err := resolveDir(cfg, dir)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			rc.contextValues[constant.TargetDir] = dir
+			rc.contextValues[constant.ContextValueInclude], _ = cmd.Flags().GetString("include")
+			rc.contextValues[constant.ContextValueExclude], _ = cmd.Flags().GetString("exclude")
+			rc.contextValues[constant.SilentMode] = constant.ContextValueYES
+			if err := rc.write(cfg, dir); err != nil {
+				log.Println("err is :", err)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&rc.from, "from", "", "branch or tag of the previous release (defaults to DIFF_BRANCH_FROM)")
+	cmd.Flags().StringVar(&rc.to, "to", "", "branch or tag of the new release (defaults to DIFF_BRANCH_TO)")
+	cmd.Flags().String("dir", "", "Directory containing the repositories and should be full path")
+	cmd.Flags().String("include", "", "include repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().String("exclude", "", "exclude repositories with patterns relative to dir (comma-separated)")
+	cmd.Flags().BoolVar(&rc.combined, "combined", false, "write one set of notes for all repositories instead of notes per repository")
+	cmd.Flags().BoolVar(&rc.fetch, "fetch", false, "Fetch the two branches or tags from origin in every repository first (defaults to DIFF_FETCH)")
+
+	return cmd
+}
+
+// write prints the release notes of the selected repositories. Repositories where the refs do not
+// exist are reported on stderr and left out.
+func (rc *ReleaseNotesCmd) write(cfg *config.Config, dir string) error {
+	gitClient, err := client.NewCLIGitClient(context.Background(), rc.contextValues, cfg)
+	if err != nil {
+		return err
+	}
+	repos, err := gitClient.Repositories()
+	if err != nil {
+		return err
+	}
+	var fetchErrs map[string]error
+	if rc.fetch {
+		fetchErrs = gitClient.FetchRefsConcurrently(repos, rc.from, rc.to)
+	}
+
+	var notes []releasenotes.Notes
+	for _, repoPath := range repos {
+		name := repoName(dir, repoPath)
+		commits, err := client.RangeCommits(repoPath, rc.from, rc.to)
+		if err != nil {
+			if fetchErr := fetchErrs[repoPath]; fetchErr != nil {
+				fmt.Fprintf(os.Stderr, "%s: skipped, fetch failed: %v\n", name, fetchErr)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s: skipped, branch or tag %s or %s does not exist\n", name, rc.from, rc.to)
+			}
+			continue
+		}
+		// Without a GitLab project, references stay plain text.
+		project, _ := client.ProjectPath(repoPath)
+		notes = append(notes, releasenotes.New(name, cfg.GitlabBaseURL, project, rc.from, rc.to, commits))
+	}
+
+	if rc.combined {
+		fmt.Println(releasenotes.Combined(rc.from, rc.to, notes))
+		return nil
+	}
+	for i, n := range notes {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(n.Markdown())
+	}
+	return nil
+}
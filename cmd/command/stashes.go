@@ -0,0 +1,128 @@
+// Package command cmd/command/stashes.go
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/spf13/cobra"
+	"log"
+	"path/filepath"
+)
+
+type StashesCmd struct {
+	contextValues map[string]string
+}
+
+func NewStashesCmd() *StashesCmd {
+	return &StashesCmd{
+		contextValues: make(map[string]string),
+	}
+}
+
+func (sc *StashesCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stashes",
+		Short: "List stashes created by Hermes across all repositories",
+		Run: func(cmd *cobra.Command, args []string) {
+			gitClient, err := sc.newClient(cmd, cfg)
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			stashes, err := gitClient.ListStashes()
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			if len(stashes) == 0 {
+				fmt.Println("No Hermes stashes found.")
+				return
+			}
+			for _, stash := range stashes {
+				fmt.Printf("%s\t%s\t%s\t%s\n", sc.repoName(cfg, stash.Repository), stash.Ref, stash.Name, stash.Created.Format("2006-01-02 15:04:05"))
+			}
+		},
+	}
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [stash-name...]",
+		Short: "Restore Hermes stashes by name (or all of them with --all), one per repository, and drop them on success",
+		Run: func(cmd *cobra.Command, args []string) {
+			all, _ := cmd.Flags().GetBool("all")
+			if len(args) == 0 && !all {
+				log.Println("specify one or more stash names or --all")
+				return
+			}
+			gitClient, err := sc.newClient(cmd, cfg)
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			stashes, err := gitClient.ListStashes()
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			names := make(map[string]bool)
+			for _, name := range args {
+				names[name] = true
+			}
+			restored := 0
+			// Restoring a stash leaves its changes in the working tree, and a stash is only restored on a
+			// clean tree, so one stash per repository is restored: the newest, as stashes are listed newest
+			// first. The others are kept and can be restored once those changes are committed.
+			restoredRepos := make(map[string]string)
+			for _, stash := range stashes {
+				if !all && !names[stash.Name] {
+					continue
+				}
+				repoName := sc.repoName(cfg, stash.Repository)
+				if first, ok := restoredRepos[stash.Repository]; ok {
+					fmt.Printf("%s: %s skipped; %s was restored into this repository, commit its changes and run restore again\n", repoName, stash.Name, first)
+					continue
+				}
+				if err := gitClient.RestoreStash(stash); err != nil {
+					fmt.Printf("%s: %s: %v\n", repoName, stash.Name, err)
+					continue
+				}
+				fmt.Printf("%s: %s restored\n", repoName, stash.Name)
+				restoredRepos[stash.Repository] = stash.Name
+				restored++
+			}
+			fmt.Printf("Restored %d stash(es).\n", restored)
+		},
+	}
+	restoreCmd.Flags().Bool("all", false, "restore every Hermes stash")
+
+	cmd.PersistentFlags().String("dir", "", "Directory containing the repositories and should be full path")
+	cmd.PersistentFlags().String("include", "", "include repositories with patterns relative to dir (comma-separated)")
+	cmd.PersistentFlags().String("exclude", "", "exclude repositories with patterns relative to dir (comma-separated)")
+	cmd.AddCommand(restoreCmd)
+
+	return cmd
+}
+
+// newClient creates a CLI client for the repositories selected by the flags.
+func (sc *StashesCmd) newClient(cmd *cobra.Command, cfg *config.Config) (*client.GitlabClient, error) {
+	dir, _ := cmd.Flags().GetString("dir")
+	dir, err := resolveDir(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	sc.contextValues[constant.TargetDir] = dir
+	sc.contextValues[constant.ContextValueInclude], _ = cmd.Flags().GetString("include")
+	sc.contextValues[constant.ContextValueExclude], _ = cmd.Flags().GetString("exclude")
+	sc.contextValues[constant.SilentMode] = constant.ContextValueYES
+	return client.NewCLIGitClient(context.Background(), sc.contextValues, cfg)
+}
+
+// repoName returns the repository path relative to the working directory when possible.
+func (sc *StashesCmd) repoName(cfg *config.Config, repoPath string) string {
+	if rel, err := filepath.Rel(cfg.WorkingDir, repoPath); err == nil {
+		return rel
+	}
+	return repoPath
+}
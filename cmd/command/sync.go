@@ -3,21 +3,60 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/sinaw369/Hermes/internal/client"
 	"github.com/sinaw369/Hermes/internal/config"
 	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/state"
 	"github.com/spf13/cobra"
 	"log"
-	"path/filepath"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 type SyncCmd struct {
 	silentMode    bool
+	every         string
+	jitter        time.Duration
 	contextValues map[string]string
 }
 
+// syncRepoSummary is the outcome of a repository that failed or needs attention.
+type syncRepoSummary struct {
+	Repository  string   `json:"repository"`
+	Error       string   `json:"error,omitempty"`
+	Diverged    []string `json:"diverged,omitempty"`
+	Pruned      []string `json:"pruned,omitempty"`
+	Protected   []string `json:"protected,omitempty"`
+	KeptStashes []string `json:"kept_stashes,omitempty"`
+	Retries     []string `json:"retries,omitempty"`
+}
+
+// syncRunSummary is written to the state directory after every sync run.
+type syncRunSummary struct {
+	Dir          string            `json:"dir"`
+	Started      time.Time         `json:"started"`
+	Finished     time.Time         `json:"finished"`
+	Repositories int               `json:"repositories"`
+	Failed       int               `json:"failed"`
+	Error        string            `json:"error,omitempty"`
+	Synced       []string          `json:"synced"` // every repository the run processed
+	Results      []syncRepoSummary `json:"results,omitempty"`
+}
+
+// syncStatus describes the running and scheduled syncs; it is shown by "hermes sync status".
+type syncStatus struct {
+	RunningPID int             `json:"running_pid,omitempty"`
+	DaemonPID  int             `json:"daemon_pid,omitempty"`
+	Every      string          `json:"every,omitempty"`
+	NextRun    *time.Time      `json:"next_run,omitempty"`
+	LastRun    *syncRunSummary `json:"last_run,omitempty"`
+}
+
 func NewSyncCmd() *SyncCmd {
 	return &SyncCmd{
 		silentMode:    false,
@@ -31,64 +70,286 @@ func (sc *SyncCmd) Command(cfg *config.Config) *cobra.Command {
 		Short: "Sync projects",
 		Run: func(cmd *cobra.Command, args []string) {
 			// Retrieve flag values or read from viper/env if not provided.
-			syncDir, _ := cmd.Flags().GetString("dir")
-			if syncDir == "" {
-				syncDir = cfg.WorkingDir
+			if err := cfg.Require(config.KeyGitlabBaseURL, config.KeyGitlabToken); err != nil {
+				log.Println(err)
+				return
+			}
+			if err := applyPreset(cmd, cfg, state.FormPull, map[string]string{
+				"dir":     constant.PullFieldPath,
+				"include": constant.ContextValueInclude,
+				"exclude": constant.ContextValueExclude,
+			}); err != nil {
+				log.Println(err)
+				return
 			}
-			if !filepath.IsAbs(syncDir) {
-				log.Println("dir should be full path:", syncDir)
+			syncDir, _ := cmd.Flags().GetString("dir")
+			syncDir, err := resolveDir(cfg, syncDir)
+			if err != nil {
+				log.Println(err)
 				return
 			}
 			sc.contextValues[constant.TargetDir] = syncDir
 			sc.contextValues[constant.ContextValueInclude], _ = cmd.Flags().GetString("include")
 			sc.contextValues[constant.ContextValueExclude], _ = cmd.Flags().GetString("exclude")
+			sc.contextValues[constant.ContextValueCloneProtocol], _ = cmd.Flags().GetString("protocol")
+			sc.contextValues[constant.ContextValueCloneStrategy], _ = cmd.Flags().GetString("clone-strategy")
+			sc.contextValues[constant.ContextValueSyncPolicy], _ = cmd.Flags().GetString("policy")
+			sc.contextValues[constant.ContextValueLockWait], _ = cmd.Flags().GetString("lock-wait")
+			if prune, _ := cmd.Flags().GetBool("prune"); prune {
+				sc.contextValues[constant.ContextValuePrune] = constant.ContextValueYES
+			}
 			pullBranch, _ := cmd.Flags().GetString("pull-branch")
 			if pullBranch != "" {
 				sc.contextValues[constant.ContextValuePullDefault] = constant.ContextValueYES
 				sc.contextValues[constant.ContextValuePullBranch] = pullBranch
 			}
+			store := state.NewStore(cfg.StateDir)
+
+			// Periodic mode keeps running until SIGINT or SIGTERM.
+			if sc.every != "" {
+				interval, err := parseInterval(sc.every)
+				if err != nil {
+					log.Println("invalid --every:", err)
+					return
+				}
+				if sc.silentMode {
+					sc.contextValues[constant.SilentMode] = "YES"
+				}
+				sc.syncEvery(store, syncDir, cfg, interval)
+				return
+			}
+
 			// Check if the user wants to detach
 			if sc.silentMode {
-				// DETACHED mode (like `docker run -d`).
+				// Quiet mode: only the start message is printed.
 				sc.contextValues[constant.SilentMode] = "YES"
 				fmt.Printf("Syncing in SilentMode mode. Press Ctrl+C to stop.\nDir=%s\n", syncDir)
-				sc.syncProjects(syncDir, cfg)
+				if err := sc.syncOnce(store, syncDir, cfg); err != nil {
+					log.Println(err)
+				}
 			} else {
 				// We block in this function, showing logs or any needed output.
 				sc.contextValues[constant.SilentMode] = "NO"
 				log.Printf("Syncing projects in %s...\n", syncDir)
 				start := time.Now()
-				sc.syncProjects(syncDir, cfg)
+				if err := sc.syncOnce(store, syncDir, cfg); err != nil {
+					log.Println(err)
+					return
+				}
 				elapsed := time.Since(start).Minutes()
 				log.Printf("Syncing projects in %s...\ndone\nelapsedtime:%v minutes", syncDir, elapsed)
 			}
 		},
 	}
-	cmd.Flags().BoolVarP(&sc.silentMode, "silent", "", false, "Run in detached mode (like Docker’s -d)")
+	cmd.Flags().BoolVarP(&sc.silentMode, "silent", "", false, "Mute log output (combine with --every for unattended periodic syncs)")
+	cmd.Flags().StringVar(&sc.every, "every", "", "Keep running and sync on this interval (e.g. 15m, 1h)")
+	cmd.Flags().DurationVar(&sc.jitter, "jitter", 0, "Maximum random delay added to each interval (defaults to a tenth of --every)")
 	cmd.Flags().String("dir", "", "Directory to sync projects and should be full path")
-	cmd.Flags().String("include", "", "include project with patterns (comma-separated)")
-	cmd.Flags().String("exclude", "", "exclude project with patterns (comma-separated)")
+	cmd.Flags().String("include", "", "include projects whose path matches these selectors (comma-separated, see INCLUDE)")
+	cmd.Flags().String("exclude", "", "exclude projects whose path matches these selectors (comma-separated, see EXCLUDE)")
 	cmd.Flags().String("pull-branch", "", "the target branch witch you want to just pull it")
+	cmd.Flags().String("protocol", "", "clone protocol: ssh or https (defaults to CLONE_PROTOCOL)")
+	cmd.Flags().String("policy", "", "sync policy: pull, fetch or ff-only (defaults to SYNC_POLICY)")
+	cmd.Flags().String("lock-wait", "", "how long to wait for a locked workspace, e.g. 5m (defaults to LOCK_WAIT; 0 fails right away)")
+	cmd.Flags().Bool("prune", false, "prune stale remote-tracking refs and merged or gone local branches after syncing")
+	cmd.Flags().String("clone-strategy", "", "clone strategy for every project: full, shallow[:depth], blobless, single-branch or mirror")
+	cmd.Flags().String("preset", "", "take --dir, --include and --exclude from a preset saved in the Pull form (flags given explicitly win)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the last sync run and when the next one is due",
+		Run: func(cmd *cobra.Command, args []string) {
+			sc.printStatus(state.NewStore(cfg.StateDir))
+		},
+	})
 
 	return cmd
 }
 
-// syncProjects is your actual sync logic.
-func (sc *SyncCmd) syncProjects(syncDir string, cfg *config.Config) {
+// syncEvery runs a sync, waits for the interval plus a random jitter and repeats until it receives
+// SIGINT or SIGTERM. A signal during a run lets the run finish and record its summary first.
+func (sc *SyncCmd) syncEvery(store *state.Store, syncDir string, cfg *config.Config, interval time.Duration) {
+	var status syncStatus
+	if err := store.Load(state.SyncStatusFile, &status); err == nil && status.DaemonPID != os.Getpid() && state.ProcessAlive(status.DaemonPID) {
+		log.Printf("a periodic sync is already running (pid %d)", status.DaemonPID)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sc.updateStatus(store, func(s *syncStatus) {
+		s.DaemonPID = os.Getpid()
+		s.Every = interval.String()
+	})
+	defer sc.updateStatus(store, func(s *syncStatus) {
+		s.DaemonPID = 0
+		s.Every = ""
+		s.NextRun = nil
+	})
+
+	jitter := sc.jitter
+	if jitter <= 0 {
+		jitter = interval / 10
+	}
+	log.Printf("Syncing %s every %s (jitter up to %s). Send SIGINT or SIGTERM to stop.", syncDir, interval, jitter)
+
+	for {
+		if err := sc.syncOnce(store, syncDir, cfg); err != nil {
+			log.Println(err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wait := interval
+		if jitter > 0 {
+			wait += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		next := time.Now().Add(wait)
+		sc.updateStatus(store, func(s *syncStatus) { s.NextRun = &next })
+		log.Printf("Next sync at %s", next.Format(time.DateTime))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	log.Println("Periodic sync stopped.")
+}
+
+// syncOnce runs a single sync and records its summary in the state directory. Overlapping runs on the
+// same workspace are prevented by the workspace lock taken by the client.
+func (sc *SyncCmd) syncOnce(store *state.Store, syncDir string, cfg *config.Config) error {
+	// The status is only written once the workspace lock is held, so a run that finds another sync
+	// working on the same tree leaves that sync's status alone.
+	locked := false
+	summary := sc.syncProjects(syncDir, cfg, func() {
+		locked = true
+		sc.updateStatus(store, func(s *syncStatus) { s.RunningPID = os.Getpid() })
+	})
+
+	if locked {
+		sc.updateStatus(store, func(s *syncStatus) {
+			s.RunningPID = 0
+			s.LastRun = &summary
+		})
+	}
+	if err := store.Append(state.SyncHistoryFile, summary, state.SyncHistoryLimit); err != nil {
+		log.Println("error writing sync history:", err)
+	}
+	if summary.Error != "" {
+		return errors.New(summary.Error)
+	}
+	return nil
+}
+
+// updateStatus loads the sync status, applies fn and saves it again.
+func (sc *SyncCmd) updateStatus(store *state.Store, fn func(s *syncStatus)) {
+	var status syncStatus
+	if err := store.Load(state.SyncStatusFile, &status); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Println("error reading sync status:", err)
+	}
+	fn(&status)
+	if err := store.Save(state.SyncStatusFile, &status); err != nil {
+		log.Println("error writing sync status:", err)
+	}
+}
+
+// syncProjects is your actual sync logic. onLocked is called once the workspace lock is held.
+func (sc *SyncCmd) syncProjects(syncDir string, cfg *config.Config, onLocked func()) syncRunSummary {
+	summary := syncRunSummary{Dir: syncDir, Started: time.Now(), Synced: []string{}}
+
 	gitClient, err := client.NewCLIGitClient(context.Background(), sc.contextValues, cfg)
+	if err == nil {
+		gitClient.OnWorkspaceLocked(onLocked)
+		err = gitClient.InitPullRequestAutomationCLI(&syncDir)
+	}
 	if err != nil {
+		summary.Error = err.Error()
+		summary.Finished = time.Now()
+		return summary
+	}
+
+	results := gitClient.Results()
+	summary.Repositories = len(results)
+	for _, r := range results {
+		summary.Synced = append(summary.Synced, r.Repository)
+		if r.Err == nil && len(r.Diverged) == 0 && len(r.Pruned) == 0 && len(r.Protected) == 0 && len(r.KeptStashes) == 0 && len(r.Retries) == 0 {
+			continue
+		}
+		repoSummary := syncRepoSummary{
+			Repository:  r.Repository,
+			Diverged:    r.Diverged,
+			Pruned:      r.Pruned,
+			Protected:   r.Protected,
+			KeptStashes: r.KeptStashes,
+			Retries:     r.Retries,
+		}
+		if r.Err != nil {
+			summary.Failed++
+			repoSummary.Error = r.Err.Error()
+		}
+		summary.Results = append(summary.Results, repoSummary)
+	}
+	summary.Finished = time.Now()
+	return summary
+}
+
+// printStatus prints the last run and the schedule recorded in the state directory.
+func (sc *SyncCmd) printStatus(store *state.Store) {
+	var status syncStatus
+	if err := store.Load(state.SyncStatusFile, &status); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Println("No sync has run yet.")
+			return
+		}
+		log.Println("error reading sync status:", err)
 		return
 	}
 
-	gitClient.InitPullRequestAutomationCLI(&syncDir)
+	if run := status.LastRun; run != nil {
+		fmt.Printf("Last run:  %s (took %s) in %s\n", run.Started.Format(time.DateTime), run.Finished.Sub(run.Started).Round(time.Second), run.Dir)
+		if run.Error != "" {
+			fmt.Printf("Result:    failed: %s\n", run.Error)
+		} else {
+			fmt.Printf("Result:    %d repositories, %d failed\n", run.Repositories, run.Failed)
+		}
+		for _, r := range run.Results {
+			if r.Error != "" {
+				fmt.Printf("  %s: %s\n", r.Repository, r.Error)
+			}
+		}
+	} else {
+		fmt.Println("Last run:  none")
+	}
 
+	if state.ProcessAlive(status.RunningPID) {
+		fmt.Printf("Running:   yes (pid %d)\n", status.RunningPID)
+	}
+	if !state.ProcessAlive(status.DaemonPID) {
+		fmt.Println("Schedule:  none (start one with 'hermes sync --every 15m')")
+		return
+	}
+	fmt.Printf("Schedule:  every %s (pid %d)\n", status.Every, status.DaemonPID)
+	if status.NextRun != nil {
+		fmt.Printf("Next run:  %s (in %s)\n", status.NextRun.Format(time.DateTime), time.Until(*status.NextRun).Round(time.Second))
+	}
 }
 
 // parseInterval is a helper to parse a duration string into time.Duration.
-func parseInterval(interval string) time.Duration {
+func parseInterval(interval string) (time.Duration, error) {
 	dur, err := time.ParseDuration(interval)
 	if err != nil {
-		return 5 * time.Minute // fallback
+		return 0, err
+	}
+	if dur < time.Minute {
+		return 0, fmt.Errorf("interval %s is shorter than one minute", interval)
 	}
-	return dur
+	return dur, nil
 }
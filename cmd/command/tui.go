@@ -19,6 +19,10 @@ func (hc *HermesCmd) Command(cfg *config.Config) *cobra.Command {
 		Short: "launching the user interface",
 		Run: func(cmd *cobra.Command, args []string) {
 			// If the user just runs "hermes", we start the TUI.
+			if err := cfg.Require(config.KeyWorkingDir); err != nil {
+				log.Println(err)
+				return
+			}
 			fmt.Println("Launching TUI...")
 			if err := hc.startTUI(cfg); err != nil {
 				log.Fatalf("Error running TUI: %v", err)
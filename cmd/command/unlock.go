@@ -0,0 +1,82 @@
+// Package command cmd/command/unlock.go
+package command
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/state"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+type UnlockCmd struct {
+	force bool
+}
+
+func NewUnlockCmd() *UnlockCmd {
+	return &UnlockCmd{}
+}
+
+func (uc *UnlockCmd) Command(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlock",
+		Short: "Remove stale workspace and repository locks left behind by crashed Hermes runs",
+		Long: "Removes lock files whose process no longer runs on this host. Locks held by a running " +
+			"process, or taken on another host, are only removed with --force.",
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, _ := cmd.Flags().GetString("dir")
+			dir, err := resolveDir(cfg, dir)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+
+			locks, err := client.LockFiles(dir)
+			if err != nil {
+				log.Println("err is :", err)
+				return
+			}
+			if len(locks) == 0 {
+				fmt.Println("No locks found.")
+				return
+			}
+			for _, path := range locks {
+				holder, err := state.ReadLock(path)
+				readable := err == nil
+				switch {
+				case err != nil:
+					// An unreadable lock cannot be checked; treat it as stale.
+					fmt.Printf("%s: %v\n", path, err)
+				case holder.Stale():
+					fmt.Printf("%s: held by %s, which is no longer running\n", path, holder)
+				case uc.force:
+					fmt.Printf("%s: held by %s, removing anyway (--force)\n", path, holder)
+				default:
+					fmt.Printf("%s: held by %s; kept (use --force to remove it)\n", path, holder)
+					continue
+				}
+				// A readable lock is only removed if it was not taken over since it was read.
+				removed := true
+				if readable {
+					removed, err = state.RemoveLock(path, holder)
+				} else {
+					err = os.Remove(path)
+				}
+				switch {
+				case err != nil:
+					fmt.Printf("%s: %v\n", path, err)
+				case !removed:
+					fmt.Printf("%s: taken over by another process in the meantime; kept\n", path)
+				default:
+					fmt.Printf("%s: removed\n", path)
+				}
+			}
+		},
+	}
+	cmd.Flags().String("dir", "", "Workspace directory and should be full path")
+	cmd.Flags().BoolVar(&uc.force, "force", false, "also remove locks held by running processes or by other hosts")
+
+	return cmd
+}
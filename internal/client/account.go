@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"gitlab.com/gitlab-org/api/client-go"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// accountTimeout bounds the requests of LookupAccount.
+const accountTimeout = 30 * time.Second
+
+// Account is the GitLab user a token belongs to and the full paths of the groups the user is a member of.
+type Account struct {
+	Username string
+	Groups   []string
+}
+
+// LookupAccount checks the token against the GitLab instance at baseURL and lists the user's groups.
+// It uses the TLS and proxy settings of cfg, so it works before the rest of the configuration exists.
+func LookupAccount(cfg *config.Config, baseURL, token string) (*Account, error) {
+	httpTransport, err := newHTTPTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	g := &GitlabClient{
+		gitlabToken:   token,
+		gitlabURL:     baseURL,
+		httpTransport: httpTransport,
+		apiTimeout:    accountTimeout,
+		logWriter:     logWriter.NewLogger(io.Discard, false, true),
+	}
+	api, err := g.createGitLabClient()
+	if err != nil {
+		return nil, err
+	}
+	user, _, err := api.Users.CurrentUser()
+	if err != nil {
+		if isStatus(err, http.StatusUnauthorized) {
+			return nil, fmt.Errorf("GitLab rejected the token; it may be invalid, expired or revoked")
+		}
+		return nil, err
+	}
+
+	account := &Account{Username: user.Username}
+	opt := &gitlab.ListGroupsOptions{ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1}}
+	for {
+		groups, resp, err := api.Groups.ListGroups(opt)
+		if err != nil {
+			return nil, fmt.Errorf("listing groups: %v", err)
+		}
+		for _, group := range groups {
+			account.Groups = append(account.Groups, group.FullPath)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	sort.Strings(account.Groups)
+	return account, nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-retryablehttp"
+	"gitlab.com/gitlab-org/api/client-go"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Bounds of the backoff between retried API requests. Waits requested by the server through
+// Retry-After or RateLimit-Reset are honoured up to apiMaxServerWait.
+const (
+	apiRetryWaitMin  = 1 * time.Second
+	apiRetryWaitMax  = 30 * time.Second
+	apiMaxServerWait = 5 * time.Minute
+)
+
+// apiCache keeps GitLab lookups that do not change during a run.
+type apiCache struct {
+	mu          sync.Mutex
+	currentUser *gitlab.User
+	projects    map[string]*gitlab.Project // by path with namespace
+}
+
+// apiClientOptions returns the options of the GitLab API client: the TLS and proxy transport, a request
+// timeout, retries with backoff on network errors, 429 and 5xx responses, and an optional client-side rate limit.
+func (g *GitlabClient) apiClientOptions() []gitlab.ClientOptionFunc {
+	options := []gitlab.ClientOptionFunc{
+		gitlab.WithBaseURL(g.gitlabURL),
+		gitlab.WithHTTPClient(&http.Client{
+			Timeout:   g.apiTimeout,
+			Transport: g.httpTransport,
+		}),
+		gitlab.WithCustomRetry(retryablehttp.DefaultRetryPolicy),
+		gitlab.WithCustomBackoff(apiBackoff),
+		gitlab.WithCustomRetryWaitMinMax(apiRetryWaitMin, apiRetryWaitMax),
+		gitlab.WithCustomRetryMax(g.apiRetries),
+		gitlab.WithRequestLogHook(func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+			if attempt > 0 {
+				g.logWriter.YellowString("Retrying GitLab API request %s %s (retry %d of %d)", req.Method, req.URL.Path, attempt, g.apiRetries)
+			}
+		}),
+	}
+	// A custom limiter replaces the one the client derives from the RateLimit-Limit header. It is the
+	// same for every API client created by g, so the limit holds across all of them.
+	if g.apiLimiter != nil {
+		options = append(options, gitlab.WithCustomLimiter(g.apiLimiter))
+	}
+	return options
+}
+
+// apiBackoff waits as long as the server asks through Retry-After or RateLimit-Reset on 429 and 503
+// responses, and backs off exponentially with jitter otherwise.
+func apiBackoff(waitMin, waitMax time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if wait, ok := serverWait(resp.Header); ok {
+			if wait > apiMaxServerWait {
+				wait = apiMaxServerWait
+			}
+			return wait + jitter(waitMin)
+		}
+	}
+	wait := retryablehttp.DefaultBackoff(waitMin, waitMax, attemptNum, nil)
+	return wait/2 + jitter(wait/2)
+}
+
+// serverWait returns how long the server asked the client to wait before retrying.
+func serverWait(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(time.Until(at), 0), true
+		}
+	}
+	if value := header.Get("RateLimit-Reset"); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil && reset > 0 {
+			return max(time.Until(time.Unix(reset, 0)), 0), true
+		}
+	}
+	return 0, false
+}
+
+// jitter returns a random duration in [0, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// currentUser returns the user the token belongs to, fetching it once per run.
+func (g *GitlabClient) currentUser(client *gitlab.Client) (*gitlab.User, error) {
+	g.cache.mu.Lock()
+	defer g.cache.mu.Unlock()
+	if g.cache.currentUser != nil {
+		return g.cache.currentUser, nil
+	}
+	user, _, err := client.Users.CurrentUser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch current user: %v", err)
+	}
+	g.cache.currentUser = user
+	return user, nil
+}
+
+// cacheProject remembers a project returned by a listing, so later lookups do not hit the API.
+func (g *GitlabClient) cacheProject(project *gitlab.Project) {
+	g.cache.mu.Lock()
+	defer g.cache.mu.Unlock()
+	if g.cache.projects == nil {
+		g.cache.projects = make(map[string]*gitlab.Project)
+	}
+	g.cache.projects[project.PathWithNamespace] = project
+}
+
+// getProject returns the project with the given path, fetching it once per run.
+func (g *GitlabClient) getProject(client *gitlab.Client, projectPath string) (*gitlab.Project, error) {
+	g.cache.mu.Lock()
+	project, ok := g.cache.projects[projectPath]
+	g.cache.mu.Unlock()
+	if ok {
+		return project, nil
+	}
+	project, _, err := client.Projects.GetProject(projectPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	g.cacheProject(project)
+	return project, nil
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"github.com/sinaw369/Hermes/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIRateLimitIsShared(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"username":"hermes"}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		GitlabBaseURL:    server.URL,
+		GitlabToken:      "token",
+		APIRateLimit:     10,
+		APITimeout:       5 * time.Second,
+		GitRetryAttempts: 1,
+	}
+	g, err := NewCLIGitClient(context.Background(), nil, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every API client starts with a full burst of one request, so with a limiter per client the
+	// requests would not wait at all.
+	const requests = 4
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		api, err := g.createGitLabClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := api.Users.CurrentUser(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed, want := time.Since(start), (requests-1)*100*time.Millisecond; elapsed < want*9/10 {
+		t.Errorf("%d requests of separate API clients took %v, want at least %v at 10 requests per second", requests, elapsed, want)
+	}
+}
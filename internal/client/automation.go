@@ -2,10 +2,13 @@ package client
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sinaw369/Hermes/internal/constant"
 	"github.com/sinaw369/Hermes/internal/form/progressScreen"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"gitlab.com/gitlab-org/api/client-go"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,28 +17,35 @@ import (
 )
 
 // InitPullRequestAutomationCLI InitPullRequestAutomation handles GitLab project automation tasks.
-func (g *GitlabClient) InitPullRequestAutomationCLI(baseDir *string) {
+// Per-repository outcomes are available from Results afterwards.
+func (g *GitlabClient) InitPullRequestAutomationCLI(baseDir *string) error {
 	if baseDir == nil {
 		// Determine the base director
-		return
+		return fmt.Errorf("base directory is not set")
 	}
 	g.logWriter.InfoString("Starting GitLab project automation")
+	unlock, err := g.lockWorkspace(*baseDir, OperationSync)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	// Initialize the GitLab client
 
 	gitlabClient, err := g.createGitLabClient()
 	if err != nil {
-		return
+		return err
 	}
 
-	// Fetch all projects
+	// Fetch all projects; an incomplete list is still synced and the error reported afterwards.
 	allProjects, err := g.fetchGitLabProjects(gitlabClient)
-	if err != nil {
-		return
+	if len(allProjects) == 0 && err != nil {
+		return err
 	}
 
 	// Process projects concurrently
 	g.processProjectsConcurrentlyCLI(allProjects, *baseDir)
+	return err
 }
 
 func (g *GitlabClient) InitPullRequestAutomationTUI(baseDir *string) {
@@ -53,6 +63,13 @@ func (g *GitlabClient) InitPullRequestAutomationTUI(baseDir *string) {
 		}
 	}
 	g.logWriter.InfoString("Starting GitLab project automation")
+	unlock, err := g.lockWorkspace(syncDir, OperationPull)
+	if err != nil {
+		g.logWriter.ErrorString("%v", err)
+		close(g.updatesChan)
+		return
+	}
+	defer unlock()
 
 	// Initialize the GitLab client
 	gitlabClient, err := g.createGitLabClient()
@@ -60,9 +77,9 @@ func (g *GitlabClient) InitPullRequestAutomationTUI(baseDir *string) {
 		return
 	}
 
-	// Fetch all projects
+	// Fetch all projects; an incomplete list is still synced.
 	allProjects, err := g.fetchGitLabProjects(gitlabClient)
-	if err != nil {
+	if len(allProjects) == 0 && err != nil {
 		return
 	}
 
@@ -72,6 +89,7 @@ func (g *GitlabClient) InitPullRequestAutomationTUI(baseDir *string) {
 
 // InitMergeAutomationFromDir walks the local directory, processes all Git repositories matching the pattern,
 // and creates merge requests. Includes support for patterns like "backend/*" and exclusions.
+// Repositories set with SetRepositories are processed instead of the ones matching the patterns.
 func (g *GitlabClient) InitMergeAutomationFromDir() {
 	g.logWriter.InfoString("Starting merge automation from directory...")
 
@@ -81,30 +99,66 @@ func (g *GitlabClient) InitMergeAutomationFromDir() {
 		g.logWriter.ErrorString("Base directory is empty")
 		return
 	}
+	unlock, err := g.lockWorkspace(baseDir, OperationMerge)
+	if err != nil {
+		g.logWriter.ErrorString("%v", err)
+		g.closeUpdates()
+		return
+	}
+	defer unlock()
 
-	// 2. Retrieve include and exclude patterns.
-	includePatterns := g.getFieldValuesWithSeparator(constant.ContextValueInclude, ",")
-	excludePatterns := g.getFieldValuesWithSeparator(constant.ContextValueExclude, ",")
-
-	// 3. Initialize a GitLab client for API operations.
+	// 2. Initialize a GitLab client for API operations.
 	gitlabClient, err := g.createGitLabClient()
 	if err != nil {
 		g.logWriter.ErrorString("Error creating GitLab client: %v", err)
 		return
 	}
 
-	// 4. Count the total number of Git repositories that match the pattern.
-	totalPackages, err := countMatchingRepositories(baseDir, includePatterns, excludePatterns)
+	// 3. Collect the repositories: the explicit selection, or the ones matching the patterns.
+	repos, err := g.mergeRepositories(baseDir)
 	if err != nil {
-		g.logWriter.ErrorString("Error counting repositories: %v", err)
-		return
+		g.logWriter.ErrorString("Error walking directory: %v", err)
 	}
 
-	// 5. Initialize a counter for the dynamic Index.
+	// 4. Process the repositories, updating the progress with a dynamic index.
 	index := 0
+	for _, path := range repos {
+		if err := g.mergeRepository(gitlabClient, path); err != nil {
+			g.logWriter.ErrorString("Error processing %s: %v", path, err)
+			continue
+		}
+		index++
+		g.sendUpdate(progressScreen.PackageUpdate{
+			PackageName: path,
+			Status:      true,
+			TotalPkg:    len(repos),
+			Index:       index,
+		})
+		g.logWriter.GreenString("Merge request created successfully for %s", path)
+	}
+
+	// Close the progress channel after processing all repositories.
+	g.closeUpdates()
+}
+
+// mergeRepositories returns the repositories of a merge campaign: the ones set with
+// SetRepositories, or the ones under baseDir matching the include selectors of the context. The
+// configured INCLUDE and EXCLUDE do not apply, so a campaign without include selectors changes
+// no repository.
+func (g *GitlabClient) mergeRepositories(baseDir string) ([]string, error) {
+	if g.repositories != nil {
+		return g.repositories, nil
+	}
+	includePatterns := g.getFieldValuesWithSeparator(constant.ContextValueInclude, ",")
+	excludePatterns := g.getFieldValuesWithSeparator(constant.ContextValueExclude, ",")
+	return matchingMergeRepositories(g.logWriter, baseDir, includePatterns, excludePatterns)
+}
 
-	// 6. Walk the base directory recursively.
-	err = filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+// matchingMergeRepositories walks baseDir and returns the Git repositories matching the include and
+// exclude patterns. Unlike findRepositories, nothing matches when there are no include patterns.
+func matchingMergeRepositories(logger *logWriter.Logger, baseDir string, includePatterns, excludePatterns []string) ([]string, error) {
+	var repos []string
+	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err // abort if there’s an error accessing a file
 		}
@@ -125,101 +179,94 @@ func (g *GitlabClient) InitMergeAutomationFromDir() {
 			relPath = path // fallback to full path
 		}
 
-		// 7. Validate repository against include/exclude rules.
-		if !isValidRepo(relPath, includePatterns, excludePatterns) {
-			g.logWriter.InfoString("Skipping repository (does not match patterns): %s", path)
+		// Validate repository against include/exclude rules.
+		if !hasSelectors(includePatterns) || !repoSelected(relPath, includePatterns, excludePatterns) {
+			logger.InfoString("Skipping repository (does not match patterns): %s", path)
 			return filepath.SkipDir
 		}
+		repos = append(repos, path)
 
-		g.logWriter.BlueString("Processing repository: %s", path)
+		// Skip processing subdirectories inside this repository.
+		return filepath.SkipDir
+	})
+	return repos, err
+}
 
-		// 8. Get the current branch.
-		currentBranch, err := getCurrentBranch(path)
-		if err != nil {
-			g.logWriter.ErrorString("Error getting current branch for %s: %v", path, err)
-			return filepath.SkipDir
-		}
+// mergeRepository creates the branch in the repository, runs the commands, pushes the changes and
+// opens the merge requests. A merge request that cannot be opened does not stop the ones into the
+// other target branches; the errors of all of them are returned together.
+func (g *GitlabClient) mergeRepository(gitlabClient *gitlab.Client, path string) error {
+	g.logWriter.BlueString("Processing repository: %s", path)
+	unlockRepo, err := g.lockRepo(g.logWriter, path, OperationMerge)
+	if err != nil {
+		return err
+	}
+	defer unlockRepo()
 
-		// 9. Handle checking out to "main" or "develop", if necessary, and resetting dirty repositories.
-		if err := checkoutAndResetBranch(path, currentBranch, g.logWriter); err != nil {
-			g.logWriter.ErrorString("Error handling branch for %s: %v", path, err)
-			return filepath.SkipDir
-		}
+	// 1. Get the current branch.
+	currentBranch, err := getCurrentBranch(path)
+	if err != nil {
+		return fmt.Errorf("error getting current branch: %v", err)
+	}
 
-		// 10. Create a new branch from the current branch.
-		branchName := g.getFieldValues(constant.MergeFieldBranch)
-		if branchName == "" {
-			g.logWriter.ErrorString("No branch name provided in context for repository: %s", path)
-			return filepath.SkipDir
-		}
-		if err := CreateBranch(g.logWriter, path, branchName, currentBranch); err != nil {
-			g.logWriter.ErrorString("Error creating branch in %s: %v", path, err)
-			return nil
-		}
+	// 2. Handle checking out to "main" or "develop", if necessary, and resetting dirty repositories.
+	if err := checkoutAndResetBranch(path, currentBranch, g.logWriter); err != nil {
+		return fmt.Errorf("error handling branch: %v", err)
+	}
 
-		// 11. Retrieve and execute the command string from context.
-		commandStr := g.getFieldValues(constant.MergeFieldCommand)
-		if err := executeCommands(g.logWriter, path, commandStr); err != nil {
-			g.logWriter.ErrorString("Error running commands for %s: %v", path, err)
-			return filepath.SkipDir
-		}
+	// 3. Create a new branch from the current branch.
+	branchName := g.getFieldValues(constant.MergeFieldBranch)
+	if branchName == "" {
+		return fmt.Errorf("no branch name provided")
+	}
+	if err := CreateBranch(g.logWriter, path, branchName, currentBranch); err != nil {
+		return fmt.Errorf("error creating branch: %v", err)
+	}
 
-		// 12. Commit changes with the provided commit message.
-		commitMsg := g.getFieldValues(constant.MergeFieldCommitMessage)
-		if err := CommitChanges(g.logWriter, path, commitMsg); err != nil {
-			g.logWriter.ErrorString("Error committing changes for %s: %v", path, err)
-			return filepath.SkipDir
-		}
+	// 4. Retrieve and execute the command string from context.
+	commandStr := g.getFieldValues(constant.MergeFieldCommand)
+	if err := executeCommands(g.logWriter, path, commandStr); err != nil {
+		return fmt.Errorf("error running commands: %v", err)
+	}
 
-		// 13. Push the new branch.
-		if err := pushBranch(g.logWriter, path); err != nil {
-			g.logWriter.ErrorString("Error pushing branch for %s: %v", path, err)
-			return nil
-		}
+	// 5. Commit changes with the provided commit message.
+	commitMsg := g.getFieldValues(constant.MergeFieldCommitMessage)
+	if err := CommitChanges(g.logWriter, path, commitMsg); err != nil {
+		return fmt.Errorf("error committing changes: %v", err)
+	}
 
-		// 14. Retrieve the GitLab project ID from the repository's remote URL.
-		projectID, err := getProjectIDFromRepo(path, gitlabClient)
-		if err != nil {
-			g.logWriter.ErrorString("Error retrieving project ID for %s: %v", path, err)
-			return nil
-		}
+	// 6. Push the new branch.
+	if err := g.pushBranch(g.logWriter, path); err != nil {
+		return fmt.Errorf("error pushing branch: %v", err)
+	}
 
-		// 15. Create the merge request.
-		targetBranch := g.getFieldValues(constant.MergeFieldMergeRequestTargetBranch)
-		titleMsg := g.getFieldValues(constant.MergeFieldMergeRequestTitle)
-		descriptionMsg := g.getFieldValues(constant.MergeFieldMergeRequestDescription)
-		if err := createMergeRequest(g.logWriter, gitlabClient, projectID, targetBranch, branchName, titleMsg, descriptionMsg); err != nil {
-			g.logWriter.ErrorString("Error creating merge request for %s: %v", path, err)
-			return nil
-		}
+	// 7. Retrieve the GitLab project ID from the repository's remote URL.
+	projectID, err := g.getProjectIDFromRepo(path, gitlabClient)
+	if err != nil {
+		return fmt.Errorf("error retrieving project ID: %v", err)
+	}
 
-		// 16. Update the progress with dynamic index and total packages.
-		index++
-		g.updatesChan <- progressScreen.PackageUpdate{
-			PackageName: path,
-			Status:      true,
-			TotalPkg:    totalPackages,
-			Index:       index,
+	// 8. Create a merge request into every target branch.
+	titleMsg := g.getFieldValues(constant.MergeFieldMergeRequestTitle)
+	descriptionMsg := g.getFieldValues(constant.MergeFieldMergeRequestDescription)
+	var errs []error
+	for _, targetBranch := range g.getFieldValuesWithSeparator(constant.MergeFieldMergeRequestTargetBranch, ",") {
+		if err := g.createMergeRequest(g.logWriter, gitlabClient, projectID, targetBranch, branchName, titleMsg, descriptionMsg); err != nil {
+			errs = append(errs, fmt.Errorf("merge request into %s: %v", targetBranch, err))
 		}
-
-		g.logWriter.GreenString("Merge request created successfully for %s", path)
-
-		// Skip processing subdirectories inside this repository.
-		return filepath.SkipDir
-	})
-
-	// Close the progress channel after processing all repositories.
-	close(g.updatesChan)
-
-	if err != nil {
-		g.logWriter.ErrorString("Error walking directory: %v", err)
 	}
+	return errors.Join(errs...)
 }
 
 // FetchDiffCLI runs a git log command between two branches (from "origin/<branchFrom>" to "origin/<branchTo>")
 // in the repository located at repoPath and returns a formatted summary along with a boolean flag indicating
 // whether differences exist.
 func (g *GitlabClient) FetchDiffCLI(repoPath, branchFrom, branchTo string) (string, bool, error) {
+	return fetchDiff(repoPath, branchFrom, branchTo)
+}
+
+// fetchDiff implements FetchDiffCLI; it does not need a client.
+func fetchDiff(repoPath, branchFrom, branchTo string) (string, bool, error) {
 	// Prepare the git log command.
 	cmd := exec.Command("git", "log", "--pretty=format:%H - %s - %ai - %ar", "origin/"+branchFrom+"..origin/"+branchTo)
 	cmd.Dir = repoPath
@@ -239,7 +286,7 @@ func (g *GitlabClient) FetchDiffCLI(repoPath, branchFrom, branchTo string) (stri
 
 	// Define a header style using lipgloss (optional for CLI formatting).
 	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("85"))
-	header := strings.TrimSpace(headerStyle.Render("Number of different commits: " + strconv.Itoa(len(lines)) + "\n"))
+	header := headerStyle.Render("Number of different commits: " + strconv.Itoa(len(lines)))
 
 	// Format each commit line.
 	var formattedLines []string
@@ -258,7 +305,7 @@ func (g *GitlabClient) FetchDiffCLI(repoPath, branchFrom, branchTo string) (stri
 		formattedLines = append(formattedLines, formattedLine)
 	}
 
-	return header + strings.Join(formattedLines, "\n"), true, nil
+	return header + "\n" + strings.Join(formattedLines, "\n"), true, nil
 }
 
 // FetchDiffCLI runs a git log command between two branches (from "origin/<branchFrom>" to "origin/<branchTo>")
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepositorySelection(t *testing.T) {
+	baseDir := t.TempDir()
+	for _, repo := range []string{"backend/api", "backend/worker", "frontend/web"} {
+		if err := os.MkdirAll(filepath.Join(baseDir, repo, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repos := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(baseDir, name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name      string
+		include   string // INCLUDE of the configuration
+		exclude   string // EXCLUDE of the configuration
+		context   map[string]string
+		wantMerge []string
+		wantRead  []string
+	}{
+		{
+			name:     "no selectors",
+			wantRead: repos("backend/api", "backend/worker", "frontend/web"),
+		},
+		{
+			name:     "configured selectors do not select merge repositories",
+			include:  "backend/*",
+			exclude:  "backend/worker",
+			wantRead: repos("backend/api"),
+		},
+		{
+			name:      "selectors of the context",
+			include:   "frontend",
+			context:   map[string]string{constant.ContextValueInclude: "backend"},
+			wantMerge: repos("backend/api", "backend/worker"),
+			wantRead:  repos("backend/api", "backend/worker"),
+		},
+		{
+			name:      "configured exclude with a context include",
+			exclude:   "backend/worker",
+			context:   map[string]string{constant.ContextValueInclude: "backend"},
+			wantMerge: repos("backend/api", "backend/worker"),
+			wantRead:  repos("backend/api"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contextMap := map[string]string{constant.TargetDir: baseDir}
+			for key, value := range tt.context {
+				contextMap[key] = value
+			}
+			cfg := &config.Config{Include: tt.include, Exclude: tt.exclude, GitRetryAttempts: 1}
+			g, err := NewCLIGitClient(context.Background(), contextMap, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merge, err := g.mergeRepositories(baseDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(merge, tt.wantMerge) {
+				t.Errorf("mergeRepositories() = %v, want %v", merge, tt.wantMerge)
+			}
+			read, err := g.Repositories()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, tt.wantRead) {
+				t.Errorf("Repositories() = %v, want %v", read, tt.wantRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/form/progressScreen"
+	"strings"
+	"sync"
+)
+
+// SetRepositories makes the client work on the given repositories instead of the ones under the
+// configured directory that match the include/exclude patterns.
+func (g *GitlabClient) SetRepositories(paths []string) {
+	g.repositories = paths
+}
+
+// PullRepositoriesTUI updates the selected repositories according to the sync policy and reports
+// the progress to the TUI.
+func (g *GitlabClient) PullRepositoriesTUI() {
+	repos, err := g.selectedRepositories()
+	if err != nil {
+		g.logWriter.ErrorString("Error finding repositories: %v", err)
+		g.closeUpdates()
+		return
+	}
+	unlock, err := g.lockWorkspace(g.getBaseDir(constant.TargetDir), OperationPull)
+	if err != nil {
+		g.logWriter.ErrorString("%v", err)
+		g.closeUpdates()
+		return
+	}
+	defer unlock()
+	g.forEachRepository(repos, func(repoPath string) error {
+		g.logWriter.BlueString("Updating repository: %s", repoPath)
+		unlock, err := g.lockRepo(g.logWriter, repoPath, OperationPull)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if err := g.updateRepo(g.logWriter, repoPath, repoPath); err != nil {
+			return err
+		}
+		if g.prune {
+			return g.pruneLocalBranches(g.logWriter, repoPath, repoPath)
+		}
+		return nil
+	})
+	g.logResults()
+	g.logWriter.GreenString("Finished processing all repositories.")
+	g.closeUpdates()
+}
+
+// RunCommandTUI runs the commands, separated by ';' or newlines, in every selected repository and
+// reports the progress to the TUI. A repository fails if any of its commands fails.
+func (g *GitlabClient) RunCommandTUI(commandStr string) {
+	repos, err := g.selectedRepositories()
+	if err != nil {
+		g.logWriter.ErrorString("Error finding repositories: %v", err)
+		g.closeUpdates()
+		return
+	}
+	unlock, err := g.lockWorkspace(g.getBaseDir(constant.TargetDir), OperationCommand)
+	if err != nil {
+		g.logWriter.ErrorString("%v", err)
+		g.closeUpdates()
+		return
+	}
+	defer unlock()
+	g.forEachRepository(repos, func(repoPath string) error {
+		unlock, err := g.lockRepo(g.logWriter, repoPath, OperationCommand)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		return runCommands(g.logWriter, repoPath, commandStr)
+	})
+	g.logResults()
+	g.logWriter.GreenString("Finished running the commands in all repositories.")
+	g.closeUpdates()
+}
+
+// forEachRepository calls fn for the repositories concurrently, records the results and sends a
+// progress update for each.
+func (g *GitlabClient) forEachRepository(repos []string, fn func(repoPath string) error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	index := 0
+	sem := make(chan struct{}, g.concurrencyOr(10)) // Limit to 10 concurrent operations by default
+	for _, repoPath := range repos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(repoPath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(repoPath)
+			g.updateResult(repoPath, func(r *RepoResult) { r.Err = err })
+			if err != nil {
+				g.logWriter.ErrorString("Error in %s: %v", repoPath, err)
+			}
+			mu.Lock()
+			index++
+			update := progressScreen.PackageUpdate{PackageName: repoPath, Status: err == nil, TotalPkg: len(repos), Index: index}
+			mu.Unlock()
+			g.sendUpdate(update)
+		}(repoPath)
+	}
+	wg.Wait()
+}
+
+// DiffReport lists, for every repository, the commits on origin/<branchTo> that are not on
+// origin/<branchFrom>. It only reads the remote-tracking branches as of the last fetch.
+func DiffReport(repos []string, branchFrom, branchTo string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Diff report %s..%s for %d repositories\n", branchFrom, branchTo, len(repos))
+	differing := 0
+	for _, repoPath := range repos {
+		summary, hasDiff, err := fetchDiff(repoPath, branchFrom, branchTo)
+		fmt.Fprintf(&b, "\n== %s (%s)\n", repoPath, RefsAge(repoPath))
+		if err != nil {
+			fmt.Fprintf(&b, "error: %v\n", err)
+			continue
+		}
+		if hasDiff {
+			differing++
+		}
+		b.WriteString(summary + "\n")
+	}
+	fmt.Fprintf(&b, "\n%d of %d repositories have differences\n", differing, len(repos))
+	return b.String()
+}
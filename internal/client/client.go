@@ -10,19 +10,43 @@ import (
 	"github.com/sinaw369/Hermes/internal/form/progressScreen"
 	"github.com/sinaw369/Hermes/internal/logWriter"
 	"gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/time/rate"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // GitlabClient manages GitLab interactions.
 type GitlabClient struct {
-	gitlabToken string
-	gitlabURL   string
-	updatesChan chan<- progressScreen.PackageUpdate
-	contextMap  map[string]string
-	logWriter   *logWriter.Logger
+	gitlabToken       string
+	gitlabURL         string
+	cloneProtocol     string
+	cloneRules        []cloneRule
+	syncPolicy        string
+	prune             bool
+	gitEnv            []string
+	httpTransport     *http.Transport
+	concurrency       int
+	lockWait          time.Duration
+	lockRepositories  bool
+	apiTimeout        time.Duration
+	apiRetries        int
+	apiLimiter        *rate.Limiter // API_RATE_LIMIT, shared by every API client of this client; nil if unlimited
+	gitRetryAttempts  int
+	gitRetryBackoff   time.Duration
+	cache             apiCache
+	updatesChan       chan<- progressScreen.PackageUpdate
+	contextMap        map[string]string
+	defaultInclude    string   // INCLUDE, for sync and the commands reading the repositories
+	defaultExclude    string   // EXCLUDE, likewise
+	repositories      []string // explicit selection replacing the include/exclude patterns
+	logWriter         *logWriter.Logger
+	onWorkspaceLocked func()
+	resultsMu         sync.Mutex
+	results           map[string]*RepoResult
 }
 
 // NewTUIGitClient is for TUI usage: it accepts an updates channel and a TUI logs model.
@@ -58,12 +82,61 @@ func newGitlabClient(
 	logsModel *logsScreen.LogModel,
 ) (*GitlabClient, error) {
 
-	// Fetch the GitLab token and URL from environment variables
+	// The GitLab token and URL are only checked once the API is used, so local-only commands work without them.
 	gitlabToken := cfg.GitlabToken
 	gitlabURL := cfg.GitlabBaseURL
 
-	if gitlabToken == "" || gitlabURL == "" {
-		return nil, fmt.Errorf("error: GITLAB_TOKEN or GITLAB_BASE_URL is not set in environment")
+	if cfg.APIRetries < 0 || cfg.APIRateLimit < 0 {
+		return nil, fmt.Errorf("error: API_RETRIES and API_RATE_LIMIT must not be negative")
+	}
+	if cfg.GitRetryAttempts < 1 || cfg.GitRetryBackoff < 0 {
+		return nil, fmt.Errorf("error: GIT_RETRY_ATTEMPTS must be at least 1 and GIT_RETRY_BACKOFF must not be negative")
+	}
+	// The clone protocol from the context (CLI flag) wins over the configured one.
+	cloneProtocol := contextMap[constant.ContextValueCloneProtocol]
+	if cloneProtocol == "" {
+		cloneProtocol = cfg.CloneProtocol
+	}
+	if cloneProtocol == "" {
+		cloneProtocol = constant.CloneProtocolSSH
+	}
+	if cloneProtocol != constant.CloneProtocolSSH && cloneProtocol != constant.CloneProtocolHTTPS {
+		return nil, fmt.Errorf("error: unsupported clone protocol %q (expected %s or %s)",
+			cloneProtocol, constant.CloneProtocolSSH, constant.CloneProtocolHTTPS)
+	}
+	// Clone strategies from the configuration; a strategy from the context applies to every project.
+	cloneRules, err := parseCloneRules(cfg.CloneStrategies)
+	if err != nil {
+		return nil, fmt.Errorf("error: CLONE_STRATEGIES: %v", err)
+	}
+	if override := contextMap[constant.ContextValueCloneStrategy]; override != "" {
+		strategy, err := parseCloneStrategy(override)
+		if err != nil {
+			return nil, fmt.Errorf("error: clone strategy: %v", err)
+		}
+		cloneRules = append([]cloneRule{{selector: "*", strategy: strategy}}, cloneRules...)
+	}
+	syncPolicy := contextMap[constant.ContextValueSyncPolicy]
+	if syncPolicy == "" {
+		syncPolicy = cfg.SyncPolicy
+	}
+	if syncPolicy == "" {
+		syncPolicy = SyncPolicyPull
+	}
+	if !validSyncPolicy(syncPolicy) {
+		return nil, fmt.Errorf("error: unsupported sync policy %q (expected %s, %s or %s)",
+			syncPolicy, SyncPolicyPull, SyncPolicyFetch, SyncPolicyFastForward)
+	}
+	lockWait := cfg.LockWait
+	if value := contextMap[constant.ContextValueLockWait]; value != "" {
+		lockWait, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("error: lock wait: %v", err)
+		}
+	}
+	httpTransport, err := newHTTPTransport(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error: %v", err)
 	}
 	// Check if the detach mode flag is set
 	disabled := false
@@ -82,16 +155,62 @@ func newGitlabClient(
 	}
 
 	client := &GitlabClient{
-		gitlabToken: gitlabToken,
-		gitlabURL:   gitlabURL,
-		updatesChan: updatesChan,
-		contextMap:  contextMap,
-		logWriter:   log,
+		gitlabToken:      gitlabToken,
+		gitlabURL:        gitlabURL,
+		cloneProtocol:    cloneProtocol,
+		cloneRules:       cloneRules,
+		syncPolicy:       syncPolicy,
+		prune:            contextMap[constant.ContextValuePrune] == constant.ContextValueYES,
+		lockWait:         lockWait,
+		lockRepositories: cfg.LockRepositories,
+		apiTimeout:       cfg.APITimeout,
+		apiRetries:       cfg.APIRetries,
+		gitRetryAttempts: cfg.GitRetryAttempts,
+		gitRetryBackoff:  cfg.GitRetryBackoff,
+		httpTransport:    httpTransport,
+		concurrency:      cfg.Concurrency,
+		updatesChan:      updatesChan,
+		contextMap:       contextMap,
+		defaultInclude:   cfg.Include,
+		defaultExclude:   cfg.Exclude,
+		logWriter:        log,
+	}
+	if cfg.APIRateLimit > 0 {
+		client.apiLimiter = rate.NewLimiter(rate.Limit(cfg.APIRateLimit), 1)
+	}
+	if cloneProtocol == constant.CloneProtocolHTTPS {
+		client.gitEnv = httpsCredentialEnv(gitlabToken)
+	}
+	client.gitEnv = gitTransportEnv(client.gitEnv, cfg)
+	if cfg.TLSInsecureSkipVerify {
+		log.RedString("TLS_INSECURE_SKIP_VERIFY is set: certificates of GitLab are not verified")
 	}
 
 	return client, nil
 }
 
+// concurrencyOr returns the configured number of repositories processed at once, or def if none is configured.
+func (g *GitlabClient) concurrencyOr(def int) int {
+	if g.concurrency > 0 {
+		return g.concurrency
+	}
+	return def
+}
+
+// sendUpdate reports progress to the TUI; CLI clients have no updates channel.
+func (g *GitlabClient) sendUpdate(update progressScreen.PackageUpdate) {
+	if g.updatesChan != nil {
+		g.updatesChan <- update
+	}
+}
+
+// closeUpdates tells the TUI that the automation is done.
+func (g *GitlabClient) closeUpdates() {
+	if g.updatesChan != nil {
+		close(g.updatesChan)
+	}
+}
+
 // getBaseDir returns the base directory for the project, either from context or default.
 func (g *GitlabClient) getBaseDir(field string) string {
 
@@ -121,6 +240,21 @@ func (g *GitlabClient) getFieldValuesWithSeparator(field, separator string) []st
 	return values
 }
 
+// selectors returns the include and exclude selectors of the context, or the configured INCLUDE
+// and EXCLUDE where the context sets none. Only sync and the commands reading the repositories use
+// them; merge automation reads the context alone (see mergeRepositories).
+func (g *GitlabClient) selectors() (include, exclude []string) {
+	include = g.getFieldValuesWithSeparator(constant.ContextValueInclude, ",")
+	if include == nil && g.defaultInclude != "" {
+		include = strings.Split(g.defaultInclude, ",")
+	}
+	exclude = g.getFieldValuesWithSeparator(constant.ContextValueExclude, ",")
+	if exclude == nil && g.defaultExclude != "" {
+		exclude = strings.Split(g.defaultExclude, ",")
+	}
+	return include, exclude
+}
+
 // getFieldValuesWithSeparator retrieves a string from the context map using the provided key,
 // splits it using the specified separator, and trims any surrounding whitespace from each value.
 func (g *GitlabClient) getFieldValues(field string) string {
@@ -134,14 +268,28 @@ func (g *GitlabClient) getFieldValues(field string) string {
 
 // createGitLabClient initializes a new GitLab client.
 func (g *GitlabClient) createGitLabClient() (*gitlab.Client, error) {
-	gitlabClient, err := gitlab.NewClient(g.gitlabToken, gitlab.WithBaseURL(g.gitlabURL))
+	if g.gitlabToken == "" || g.gitlabURL == "" {
+		err := fmt.Errorf("GITLAB_TOKEN or GITLAB_BASE_URL is not set")
+		g.logWriter.RedString("Error creating GitLab client: %v", err)
+		return nil, err
+	}
+	gitlabClient, err := gitlab.NewClient(g.gitlabToken, g.apiClientOptions()...)
 	if err != nil {
 		g.logWriter.RedString("Error creating GitLab client: %v", err)
 	}
 	return gitlabClient, err
 }
 
-// fetchGitLabProjects retrieves all projects from GitLab.
+// projectCloneURL returns the URL to clone the project with, depending on the clone protocol.
+func (g *GitlabClient) projectCloneURL(project *gitlab.Project) string {
+	if g.cloneProtocol == constant.CloneProtocolHTTPS {
+		return project.HTTPURLToRepo
+	}
+	return project.SSHURLToRepo
+}
+
+// fetchGitLabProjects retrieves all projects from GitLab. Failed requests are retried by the API client;
+// if a page still fails, the projects listed so far are returned together with the error.
 func (g *GitlabClient) fetchGitLabProjects(client *gitlab.Client) ([]*gitlab.Project, error) {
 	listOptions := &gitlab.ListProjectsOptions{
 		ListOptions: gitlab.ListOptions{PerPage: 100, Page: 1},
@@ -151,18 +299,23 @@ func (g *GitlabClient) fetchGitLabProjects(client *gitlab.Client) ([]*gitlab.Pro
 	for {
 		projects, resp, err := client.Projects.ListProjects(listOptions)
 		if err != nil {
-			g.logWriter.ErrorString("Error fetching GitLab projects: %v", err)
+			g.logWriter.ErrorString("Error fetching GitLab projects (page %d): %v", listOptions.Page, err)
+			if len(allProjects) > 0 {
+				return allProjects, fmt.Errorf("project list incomplete, stopped at page %d: %v", listOptions.Page, err)
+			}
 			return nil, err
 		}
 
 		for _, project := range projects {
+			g.cacheProject(project)
 			if g.shouldIncludeProject(project) {
 				allProjects = append(allProjects, project)
 				g.logWriter.YellowString("Appended project: %s", project.SSHURLToRepo)
 			}
 		}
 
-		if resp.CurrentPage >= resp.TotalPages {
+		// GitLab omits the total page count for very large result sets, so follow the next page instead.
+		if resp.NextPage == 0 {
 			break
 		}
 		listOptions.Page = resp.NextPage
@@ -171,50 +324,21 @@ func (g *GitlabClient) fetchGitLabProjects(client *gitlab.Client) ([]*gitlab.Pro
 	return allProjects, nil
 }
 
+// shouldIncludeProject reports whether the project is selected by the include and exclude selectors
+// of the context, matched against its path with namespace (see selectorMatches).
 func (g *GitlabClient) shouldIncludeProject(project *gitlab.Project) bool {
-	// Retrieve filtering values from the context map.
-	includeField := g.contextMap[constant.ContextValueInclude]
-	excludeField := g.contextMap[constant.ContextValueExclude]
-
-	// If all filter fields are empty, include the project.
-	if includeField == "" && excludeField == "" {
-		return true
-	}
-
-	// Check includeField: if provided, split on commas and require at least one match.
-	if includeField != "" {
-		parts := strings.Split(includeField, ",")
-		matched := false
-		for _, part := range parts {
-			trimmed := strings.TrimSpace(part)
-			if trimmed != "" && strings.Contains(project.SSHURLToRepo, trimmed) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
+	projectPath := project.PathWithNamespace
+	if projectPath == "" {
+		projectPath, _ = projectPathFromURL(project.SSHURLToRepo)
 	}
-
-	// Check excludeField: if provided, split on commas and if any part matches, exclude the project.
-	if excludeField != "" {
-		parts := strings.Split(excludeField, ",")
-		for _, part := range parts {
-			trimmed := strings.TrimSpace(part)
-			if trimmed != "" && strings.Contains(project.SSHURLToRepo, trimmed) {
-				return false
-			}
-		}
-	}
-
-	return true
+	includePatterns, excludePatterns := g.selectors()
+	return repoSelected(projectPath, includePatterns, excludePatterns)
 }
 
 // processProjectsConcurrently processes the projects with concurrency.
 func (g *GitlabClient) processProjectsConcurrentlyTUI(projects []*gitlab.Project, baseDir string) {
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 10) // Limit to 10 concurrent operations
+	sem := make(chan struct{}, g.concurrencyOr(10)) // Limit to 10 concurrent operations by default
 
 	for idx, project := range projects {
 		wg.Add(1)
@@ -223,10 +347,11 @@ func (g *GitlabClient) processProjectsConcurrentlyTUI(projects []*gitlab.Project
 			defer wg.Done()
 			defer func() { <-sem }() // Release semaphore
 
-			repoURL := project.SSHURLToRepo
+			repoURL := g.projectCloneURL(project)
 			g.logWriter.BlueString("Processing repository: %s", repoURL)
 
 			err := g.CloneOrPullRepo(g.logWriter, repoURL, baseDir)
+			g.updateResult(repoURL, func(r *RepoResult) { r.Err = err })
 			if err != nil {
 				g.logWriter.ErrorString("Error cloning/pulling repository: %v", err)
 				g.updatesChan <- progressScreen.PackageUpdate{
@@ -248,6 +373,7 @@ func (g *GitlabClient) processProjectsConcurrentlyTUI(projects []*gitlab.Project
 	}
 
 	wg.Wait()
+	g.logResults()
 	g.logWriter.GreenString("Finished processing all repositories.")
 	close(g.updatesChan)
 }
@@ -255,7 +381,7 @@ func (g *GitlabClient) processProjectsConcurrentlyTUI(projects []*gitlab.Project
 // processProjectsConcurrently processes the projects with concurrency.
 func (g *GitlabClient) processProjectsConcurrentlyCLI(projects []*gitlab.Project, baseDir string) {
 	var wg sync.WaitGroup
-	sem := make(chan struct{}, 20) // Limit to 10 concurrent operations
+	sem := make(chan struct{}, g.concurrencyOr(20)) // Limit to 20 concurrent operations by default
 
 	for idx, project := range projects {
 		wg.Add(1)
@@ -264,10 +390,11 @@ func (g *GitlabClient) processProjectsConcurrentlyCLI(projects []*gitlab.Project
 			defer wg.Done()
 			defer func() { <-sem }() // Release semaphore
 
-			repoURL := project.SSHURLToRepo
+			repoURL := g.projectCloneURL(project)
 			g.logWriter.BlueString("Processing repository: %s", repoURL)
 
 			err := g.CloneOrPullRepo(g.logWriter, repoURL, baseDir)
+			g.updateResult(repoURL, func(r *RepoResult) { r.Err = err })
 			if err != nil {
 				g.logWriter.ErrorString("Error cloning/pulling repository: %v", err)
 				return
@@ -276,5 +403,6 @@ func (g *GitlabClient) processProjectsConcurrentlyCLI(projects []*gitlab.Project
 	}
 
 	wg.Wait()
+	g.logResults()
 	g.logWriter.GreenString("Finished processing all repositories.")
 }
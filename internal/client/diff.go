@@ -0,0 +1,145 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commit is a commit found between two branches.
+type Commit struct {
+	Hash    string    `json:"hash"`
+	Subject string    `json:"subject"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Body    string    `json:"-"`
+	// MergeRequest is the merge request the commit was merged with, e.g. "group/project!123",
+	// as named by the merge commit GitLab created. Only RangeCommits sets it.
+	MergeRequest string `json:"-"`
+}
+
+// mergeRequestLine matches the line GitLab writes into the message of merge commits.
+var mergeRequestLine = regexp.MustCompile(`(?m)^See merge request (\S+![0-9]+)`)
+
+// DiffCommits returns the commits of origin/<branchTo> that are not on origin/<branchFrom>, newest
+// first, the same range FetchDiffCLI summarizes.
+func DiffCommits(repoPath, branchFrom, branchTo string) ([]Commit, error) {
+	return commitsBetween(repoPath, "origin/"+branchFrom, "origin/"+branchTo)
+}
+
+// RangeCommits is DiffCommits for names that are remote branches or tags, so it also covers the
+// changes between two releases. Merge commits are left out; the merge request named by a merge
+// commit is recorded in the commits it merged.
+func RangeCommits(repoPath, from, to string) ([]Commit, error) {
+	fromRev, toRev := Revision(repoPath, from), Revision(repoPath, to)
+	commits, err := commitsBetween(repoPath, fromRev, toRev, "--no-merges")
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergedRequests(repoPath, fromRev, toRev)
+	if err != nil {
+		return nil, err
+	}
+	for i := range commits {
+		commits[i].MergeRequest = merged[commits[i].Hash]
+	}
+	return commits, nil
+}
+
+// mergedRequests maps the commits merged by the merge commits between from and to to the merge
+// request named in the merge commit. A commit merged more than once keeps the first merge request.
+func mergedRequests(repoPath, from, to string) (map[string]string, error) {
+	out, err := gitOutput(repoPath, "log", "--merges", "--reverse", "--format=%P%x1f%b%x1e", from+".."+to, "--")
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string)
+	for _, record := range strings.Split(out, "\x1e") {
+		parents, body, _ := strings.Cut(strings.TrimSpace(record), "\x1f")
+		match := mergeRequestLine.FindStringSubmatch(body)
+		fields := strings.Fields(parents)
+		if match == nil || len(fields) < 2 {
+			continue
+		}
+		hashes, err := gitOutput(repoPath, "rev-list", fields[0]+".."+fields[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range strings.Fields(hashes) {
+			if _, ok := merged[hash]; !ok {
+				merged[hash] = match[1]
+			}
+		}
+	}
+	return merged, nil
+}
+
+// Revision returns the revision git uses for a name: the remote branch of origin with that name,
+// or else the tag.
+func Revision(repoPath, name string) string {
+	if _, err := gitOutput(repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+name); err != nil {
+		if _, err := gitOutput(repoPath, "rev-parse", "--verify", "--quiet", "refs/tags/"+name); err == nil {
+			return "refs/tags/" + name
+		}
+	}
+	return "origin/" + name
+}
+
+// commitsBetween returns the commits reachable from to and not from from, newest first.
+func commitsBetween(repoPath, from, to string, options ...string) ([]Commit, error) {
+	args := append([]string{"log", "--format=%H%x1f%s%x1f%an%x1f%aI%x1f%b%x1e"}, options...)
+	out, err := gitOutput(repoPath, append(args, from+".."+to, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		commits = append(commits, Commit{Hash: fields[0], Subject: fields[1], Author: fields[2], Date: date, Body: strings.TrimSpace(fields[4])})
+	}
+	return commits, nil
+}
+
+// FetchRefs fetches only the named branches from origin into their remote-tracking refs, so a diff
+// between them is current without fetching the whole repository. A name that is a local tag and
+// not a remote branch is fetched as a tag.
+func (g *GitlabClient) FetchRefs(repoPath string, names ...string) error {
+	args := []string{"fetch", "--no-tags", "origin"}
+	for _, name := range names {
+		if strings.HasPrefix(Revision(repoPath, name), "refs/tags/") {
+			args = append(args, "+refs/tags/"+name+":refs/tags/"+name)
+		} else {
+			args = append(args, "+refs/heads/"+name+":refs/remotes/origin/"+name)
+		}
+	}
+	return g.runGitWithRetry(g.logWriter, repoPath, repoPath, args...)
+}
+
+// FetchRefsConcurrently runs FetchRefs in every repository, CONCURRENCY at a time, and returns the
+// errors by repository path.
+func (g *GitlabClient) FetchRefsConcurrently(repos []string, names ...string) map[string]error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	sem := make(chan struct{}, g.concurrencyOr(10)) // Limit to 10 concurrent operations by default
+	for _, repoPath := range repos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(repoPath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := g.FetchRefs(repoPath, names...); err != nil {
+				mu.Lock()
+				errs[repoPath] = err
+				mu.Unlock()
+			}
+		}(repoPath)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,445 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"gitlab.com/gitlab-org/api/client-go"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of a doctor check.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// Thresholds of the doctor checks.
+const (
+	doctorTimeout    = 15 * time.Second
+	minGitVersion    = "2.31" // GIT_CONFIG_COUNT, used for the HTTPS credential helper and TLS settings
+	lowDiskSpace     = 1 << 30
+	tokenExpiryAlert = 7 * 24 * time.Hour
+)
+
+// Check is the outcome of one doctor check, with a hint on how to fix it if it did not pass.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// doctor runs the checks in order; later checks are skipped when the ones they depend on failed.
+type doctor struct {
+	cfg    *config.Config
+	client *GitlabClient
+	api    *gitlab.Client
+	checks []Check
+}
+
+func (d *doctor) add(name, status, detail, hint string) {
+	d.checks = append(d.checks, Check{Name: name, Status: status, Detail: detail, Hint: hint})
+}
+
+// Diagnose checks the configuration and the environment Hermes runs in: token resolution, access to
+// GitLab and the token's scopes, git, SSH access and the working directory. loadErr is the error of
+// loading the configuration, if any; cfg is not used then.
+func Diagnose(cfg *config.Config, loadErr error) []Check {
+	d := &doctor{cfg: cfg}
+	if loadErr != nil {
+		d.add("configuration", CheckFail, loadErr.Error(), "fix the listed keys in .env, the environment or "+config.FilePath())
+		d.checkGit()
+		return d.checks
+	}
+	d.checkConfig()
+	d.checkToken()
+	d.checkReachable()
+	d.checkAuth()
+	d.checkScopes()
+	d.checkGit()
+	d.checkSSH()
+	d.checkWorkingDir()
+	d.checkDiskSpace()
+	return d.checks
+}
+
+func (d *doctor) checkConfig() {
+	// The client constructor validates the clone, sync, lock, retry and TLS settings.
+	client, err := NewCLIGitClient(context.Background(), map[string]string{constant.SilentMode: constant.ContextValueYES}, d.cfg)
+	if err != nil {
+		d.add("configuration", CheckFail, strings.TrimPrefix(err.Error(), "error: "), "fix the setting in .env, the environment or "+config.FilePath())
+		return
+	}
+	// Doctor reports problems instead of waiting them out.
+	client.apiRetries = 0
+	client.apiTimeout = doctorTimeout
+	d.client = client
+
+	detail := "loaded from .env and the environment"
+	if d.cfg.Profile != "" {
+		detail = fmt.Sprintf("profile %q of %s", d.cfg.Profile, d.cfg.File)
+	}
+	d.add("configuration", CheckPass, detail, "")
+}
+
+func (d *doctor) checkToken() {
+	if d.client == nil {
+		d.add("token", CheckSkip, "configuration is invalid", "")
+		return
+	}
+	if err := d.cfg.Require(config.KeyGitlabToken); err != nil {
+		d.add("token", CheckFail, err.Error(),
+			"set GITLAB_TOKEN, or GITLAB_TOKEN_COMMAND, GITLAB_TOKEN_FILE, GITLAB_TOKEN_ENV or GITLAB_TOKEN_CREDENTIAL")
+		return
+	}
+	// The token was only resolved now; the client was created with the unresolved value.
+	d.client.gitlabToken = d.cfg.GitlabToken
+	d.add("token", CheckPass, "read from "+d.cfg.TokenSource(), "")
+}
+
+func (d *doctor) checkReachable() {
+	if d.client == nil {
+		d.add("gitlab reachable", CheckSkip, "configuration is invalid", "")
+		return
+	}
+	if err := d.cfg.Require(config.KeyGitlabBaseURL); err != nil {
+		d.add("gitlab reachable", CheckFail, err.Error(), "set GITLAB_BASE_URL to the address of your GitLab instance, e.g. https://gitlab.example.com")
+		return
+	}
+	base, err := url.Parse(d.cfg.GitlabBaseURL)
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		d.add("gitlab reachable", CheckFail, fmt.Sprintf("GITLAB_BASE_URL %q is not an http(s) URL", d.cfg.GitlabBaseURL),
+			"set GITLAB_BASE_URL to the address of your GitLab instance, e.g. https://gitlab.example.com")
+		return
+	}
+	httpClient := &http.Client{Timeout: doctorTimeout, Transport: d.client.httpTransport}
+	start := time.Now()
+	// The version endpoint answers 401 without a token, which still proves the instance is reachable.
+	resp, err := httpClient.Get(strings.TrimSuffix(d.cfg.GitlabBaseURL, "/") + "/api/v4/version")
+	if err != nil {
+		d.add("gitlab reachable", CheckFail, err.Error(), reachHint(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode >= http.StatusInternalServerError {
+		d.add("gitlab reachable", CheckFail, fmt.Sprintf("%s answered %s", base.Host, resp.Status),
+			"check that GITLAB_BASE_URL points to the GitLab instance itself and not to a group or project")
+		return
+	}
+	d.add("gitlab reachable", CheckPass, fmt.Sprintf("%s answered in %s", base.Host, time.Since(start).Round(time.Millisecond)), "")
+}
+
+// reachHint suggests a fix for a failed request to GitLab.
+func reachHint(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "certificate") || strings.Contains(msg, "x509") || strings.Contains(msg, "tls"):
+		return "set TLS_CA_FILE to the CA bundle of your GitLab instance, or TLS_CERT_FILE and TLS_KEY_FILE if it requires a client certificate"
+	case strings.Contains(msg, "proxy"):
+		return "check PROXY_URL or the HTTPS_PROXY environment variable"
+	case strings.Contains(msg, "no such host"):
+		return "check the host name in GITLAB_BASE_URL and your DNS or VPN connection"
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline"):
+		return "check your network, VPN or proxy (PROXY_URL); raise API_TIMEOUT for slow instances"
+	}
+	return "check GITLAB_BASE_URL and your network connection"
+}
+
+func (d *doctor) checkAuth() {
+	if d.client == nil || d.client.gitlabToken == "" || d.cfg.GitlabBaseURL == "" || d.failed("gitlab reachable") {
+		d.add("token valid", CheckSkip, "needs a token and a reachable GitLab", "")
+		return
+	}
+	api, err := d.client.createGitLabClient()
+	if err != nil {
+		d.add("token valid", CheckFail, err.Error(), "check GITLAB_BASE_URL")
+		return
+	}
+	user, _, err := api.Users.CurrentUser()
+	if err != nil {
+		hint := "check your network connection"
+		if isStatus(err, http.StatusUnauthorized) {
+			hint = "the token is invalid, expired or revoked; create a personal access token under User Settings > Access tokens"
+		}
+		d.add("token valid", CheckFail, err.Error(), hint)
+		return
+	}
+	d.api = api
+	d.add("token valid", CheckPass, "authenticated as @"+user.Username, "")
+}
+
+func (d *doctor) checkScopes() {
+	if d.api == nil {
+		d.add("token scopes", CheckSkip, "token is not valid", "")
+		return
+	}
+	token, _, err := d.api.PersonalAccessTokens.GetSinglePersonalAccessToken()
+	if err != nil {
+		// Older instances and tokens other than personal access tokens cannot be inspected.
+		d.add("token scopes", CheckSkip, "scopes cannot be read: "+err.Error(), "")
+		return
+	}
+	scopes := strings.Join(token.Scopes, ", ")
+	if token.ExpiresAt != nil {
+		if left := time.Until(time.Time(*token.ExpiresAt)); left < tokenExpiryAlert {
+			d.add("token scopes", CheckWarn, fmt.Sprintf("%s; the token expires on %s", scopes, token.ExpiresAt),
+				"rotate the token before it expires")
+			return
+		}
+	}
+	if slices.Contains(token.Scopes, "api") {
+		d.add("token scopes", CheckPass, scopes, "")
+		return
+	}
+	if !slices.Contains(token.Scopes, "read_api") {
+		d.add("token scopes", CheckFail, scopes, "add the read_api scope to list projects, or api to also create merge requests")
+		return
+	}
+	missing := []string{"api"}
+	hint := "add the api scope to create merge requests"
+	if d.client.cloneProtocol == constant.CloneProtocolHTTPS && !slices.Contains(token.Scopes, "write_repository") {
+		missing = append(missing, "write_repository")
+		hint = "add the api scope to create merge requests, or write_repository to push over HTTPS"
+	}
+	d.add("token scopes", CheckWarn, fmt.Sprintf("%s; missing %s", scopes, strings.Join(missing, ", ")), hint)
+}
+
+// gitVersionPattern matches the version printed by "git version", e.g. "git version 2.43.0".
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+func (d *doctor) checkGit() {
+	out, err := exec.Command("git", "version").Output()
+	if err != nil {
+		d.add("git", CheckFail, err.Error(), "install git and make sure it is on your PATH")
+		return
+	}
+	version := strings.TrimSpace(string(out))
+	match := gitVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		d.add("git", CheckWarn, version, "the git version could not be read")
+		return
+	}
+	if compareVersions(match[1:], strings.Split(minGitVersion, ".")) < 0 {
+		d.add("git", CheckWarn, version, "upgrade git to "+minGitVersion+" or later; older versions cannot clone over HTTPS with the token")
+		return
+	}
+	d.add("git", CheckPass, version, "")
+}
+
+// compareVersions compares dotted numeric versions part by part.
+func compareVersions(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, _ := strconv.Atoi(a[i])
+		y, _ := strconv.Atoi(b[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(a) - len(b)
+}
+
+func (d *doctor) checkSSH() {
+	if d.client == nil {
+		d.add("ssh", CheckSkip, "configuration is invalid", "")
+		return
+	}
+	if d.client.cloneProtocol != constant.CloneProtocolSSH {
+		d.add("ssh", CheckSkip, "CLONE_PROTOCOL is "+d.client.cloneProtocol, "")
+		return
+	}
+	user, host, port := d.sshTarget()
+	if host == "" {
+		d.add("ssh", CheckSkip, "the SSH host of GitLab is unknown", "")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	args := []string{"-T", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(args, user+"@"+host)...)
+	// GitLab greets with "Welcome to GitLab, @user!"; some versions exit with a non-zero status anyway.
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if strings.Contains(output, "Welcome to GitLab") {
+		d.add("ssh", CheckPass, output, "")
+		return
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		d.add("ssh", CheckFail, err.Error(), "install an SSH client, or set CLONE_PROTOCOL=https")
+		return
+	}
+	detail := output
+	if detail == "" && err != nil {
+		detail = err.Error()
+	}
+	hint := "add your SSH key to GitLab under User Settings > SSH Keys and load it into ssh-agent, or set CLONE_PROTOCOL=https"
+	switch lower := strings.ToLower(output); {
+	case strings.Contains(lower, "could not resolve hostname"):
+		hint = fmt.Sprintf("check that %s resolves, e.g. through your DNS or VPN connection", host)
+	case strings.Contains(lower, "connection refused") || strings.Contains(lower, "timed out"):
+		hint = fmt.Sprintf("check that SSH on %s is reachable from your network, or set CLONE_PROTOCOL=https", host)
+	case strings.Contains(lower, "host key verification failed"):
+		hint = fmt.Sprintf("add the host key of %s to ~/.ssh/known_hosts, e.g. by running 'ssh -T %s@%s' once", host, user, host)
+	}
+	d.add("ssh", CheckFail, detail, hint)
+}
+
+// sshTarget returns the user, host and port of GitLab's SSH clone URLs. The host is taken from a
+// project's SSH URL when the API is available, as it may differ from the web host.
+func (d *doctor) sshTarget() (user, host, port string) {
+	user = "git"
+	if base, err := url.Parse(d.cfg.GitlabBaseURL); err == nil {
+		host = base.Hostname()
+	}
+	if d.api == nil {
+		return user, host, ""
+	}
+	projects, _, err := d.api.Projects.ListProjects(&gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		Membership:  gitlab.Ptr(true),
+	})
+	if err != nil || len(projects) == 0 || projects[0].SSHURLToRepo == "" {
+		return user, host, ""
+	}
+	sshURL := projects[0].SSHURLToRepo
+	// ssh://git@host:port/group/project.git
+	if u, err := url.Parse(sshURL); err == nil && u.Scheme == "ssh" {
+		if u.User != nil {
+			user = u.User.Username()
+		}
+		return user, u.Hostname(), u.Port()
+	}
+	// git@host:group/project.git
+	if target, _, ok := strings.Cut(sshURL, ":"); ok {
+		if name, h, ok := strings.Cut(target, "@"); ok {
+			return name, h, ""
+		}
+		return user, target, ""
+	}
+	return user, host, ""
+}
+
+func (d *doctor) checkWorkingDir() {
+	if d.client == nil {
+		d.add("working directory", CheckSkip, "configuration is invalid", "")
+		return
+	}
+	if err := d.cfg.Require(config.KeyWorkingDir); err != nil {
+		d.add("working directory", CheckFail, err.Error(), "set WORKING_DIR to the full path repositories are cloned into")
+		return
+	}
+	dir := d.cfg.WorkingDir
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// sync creates the directory, so only its nearest existing parent must be writable.
+		parent := existingParent(dir)
+		if err := checkWritable(parent); err != nil {
+			d.add("working directory", CheckFail, fmt.Sprintf("%s does not exist and %v", dir, err),
+				fmt.Sprintf("create %s or choose a WORKING_DIR you can write to", dir))
+			return
+		}
+		d.add("working directory", CheckWarn, dir+" does not exist yet", "hermes sync creates it")
+		return
+	case err != nil:
+		d.add("working directory", CheckFail, err.Error(), "choose a WORKING_DIR you can access")
+		return
+	case !info.IsDir():
+		d.add("working directory", CheckFail, dir+" is not a directory", "set WORKING_DIR to a directory")
+		return
+	}
+	if err := checkWritable(dir); err != nil {
+		d.add("working directory", CheckFail, err.Error(), fmt.Sprintf("make %s writable, e.g. with 'chmod u+w %s'", dir, dir))
+		return
+	}
+	d.add("working directory", CheckPass, dir+" is writable", "")
+}
+
+// existingParent returns dir or its nearest ancestor that exists.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// checkWritable creates and removes a temporary file in dir.
+func checkWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".hermes-doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+func (d *doctor) checkDiskSpace() {
+	if d.client == nil || d.cfg.WorkingDir == "" {
+		d.add("disk space", CheckSkip, "WORKING_DIR is not set", "")
+		return
+	}
+	dir := existingParent(d.cfg.WorkingDir)
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		d.add("disk space", CheckSkip, err.Error(), "")
+		return
+	}
+	detail := fmt.Sprintf("%s free on %s", formatBytes(free), dir)
+	if free < lowDiskSpace {
+		d.add("disk space", CheckWarn, detail, "free up space or use a clone strategy such as blobless or shallow in CLONE_STRATEGIES")
+		return
+	}
+	d.add("disk space", CheckPass, detail, "")
+}
+
+// formatBytes formats a size with a binary unit, e.g. "12.3 GiB".
+func formatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// failed reports whether the named check has failed.
+func (d *doctor) failed(name string) bool {
+	for _, check := range d.checks {
+		if check.Name == name {
+			return check.Status == CheckFail
+		}
+	}
+	return false
+}
+
+// isStatus reports whether err is a GitLab API error response with the given status code.
+func isStatus(err error, code int) bool {
+	var errResp *gitlab.ErrorResponse
+	return errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == code
+}
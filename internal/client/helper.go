@@ -2,28 +2,75 @@ package client
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/sinaw369/Hermes/internal/constant"
 	"github.com/sinaw369/Hermes/internal/logWriter"
 	"gitlab.com/gitlab-org/api/client-go"
 	"golang.org/x/sync/errgroup"
 	"io"
+	"maps"
 	"net/url"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// gitTokenEnv is the environment variable the inline credential helper reads the GitLab token from.
+const gitTokenEnv = "HERMES_GIT_TOKEN"
+
+// httpsCredentialEnv returns the environment that lets git authenticate HTTPS remotes with the token.
+// The credential helper is injected through GIT_CONFIG_* variables, so neither the helper nor the token
+// is ever written to .git/config. The first empty helper resets any helpers configured by the user.
+func httpsCredentialEnv(token string) []string {
+	return []string{
+		gitTokenEnv + "=" + token,
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=2",
+		"GIT_CONFIG_KEY_0=credential.helper",
+		"GIT_CONFIG_VALUE_0=",
+		"GIT_CONFIG_KEY_1=credential.helper",
+		`GIT_CONFIG_VALUE_1=!f() { test "$1" = get && echo username=oauth2 && echo "password=$` + gitTokenEnv + `"; }; f`,
+	}
+}
+
+// commandError is returned when a command exits with an error. It keeps the command's stderr,
+// so callers can tell why it failed.
+type commandError struct {
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	if reason := stderrReason(e.stderr); reason != "" {
+		return fmt.Sprintf("%v: %s", e.err, reason)
+	}
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
 // runCommand executes a shell command in the specified directory and logs its output.
 // It uses a context to allow cancellation/timeouts and errgroup to run stdout and stderr reading concurrently.
 func runCommand(logger *logWriter.Logger, dir, command string, args ...string) error {
+	return runCommandEnv(logger, nil, dir, command, args...)
+}
+
+// runCommandEnv is like runCommand but appends env to the environment of the process.
+func runCommandEnv(logger *logWriter.Logger, env []string, dir, command string, args ...string) error {
 	// Create the command with context support.
 	cmd := exec.Command(command, args...)
 	if dir != "" {
 		cmd.Dir = dir
 	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	logger.BlueString("Running command: %s args: %v", command, args)
 
@@ -46,13 +93,18 @@ func runCommand(logger *logWriter.Logger, dir, command string, args ...string) e
 	}
 
 	// Use errgroup to concurrently read from stdout and stderr.
+	// Stderr is also kept to explain a failure.
 	var g errgroup.Group
+	var stderr bytes.Buffer
 
 	g.Go(func() error {
 		return scanAndLog(stdoutPipe, "STDOUT", logger)
 	})
 	g.Go(func() error {
-		return scanAndLog(stderrPipe, "STDERR", logger)
+		return scanAndLog(struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(stderrPipe, &stderr), stderrPipe}, "STDERR", logger)
 	})
 
 	// Wait for the output scanning goroutines.
@@ -65,7 +117,7 @@ func runCommand(logger *logWriter.Logger, dir, command string, args ...string) e
 	if err := cmd.Wait(); err != nil {
 		logger.ErrorString("Command execution failed: %v", err)
 		logger.ErrorString("dir:%v ,command:%v, args:%v", dir, command, args)
-		return err
+		return &commandError{err: err, stderr: stderr.String()}
 	}
 	return nil
 }
@@ -98,8 +150,10 @@ func scanAndLog(pipe io.ReadCloser, prefix string, logger *logWriter.Logger) err
 
 // CloneOrPullRepo updates a repository by either pulling only the default branch (if configured)
 // or pulling all remote branches.
-// It stashes any uncommitted changes before pulling and then applies the stash using "git stash apply".
-// If conflicts occur during stash apply, it aborts the merge and resets the repository to a safe commit.
+// It stashes any uncommitted changes, including untracked files, under a "hermes-<timestamp>-<branch>"
+// name before pulling and applies that stash afterwards. If the stash does not apply cleanly, the
+// working tree is reset to HEAD and the stash is kept and recorded in the repository result.
+// The fetch-only and fast-forward-only sync policies never stash, check out or merge.
 func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDir string) error {
 	// Ensure the base directory exists.
 	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
@@ -110,30 +164,58 @@ func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDi
 	}
 
 	// Parse the repository URL.
-	u, err := url.Parse(repoURL)
+	projectPath, err := projectPathFromURL(repoURL)
 	if err != nil {
 		return err
 	}
-	trimPrefix := strings.TrimPrefix(u.Path, "/")
-	trimPrefix = strings.TrimSuffix(trimPrefix, ".git")
-	repoPath := filepath.Join(baseDir, trimPrefix)
+	repoPath := filepath.Join(baseDir, projectPath)
+
+	// Mirrors are bare repositories kept next to the working copies and have their own update path.
+	strategy := g.cloneStrategyFor(projectPath)
+	if strategy.Mode == CloneStrategyMirror {
+		return g.cloneOrUpdateMirror(logger, repoURL, repoPath+".git", strategy)
+	}
 
 	// If the repository doesn't exist locally, clone it.
 	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
-		logger.BlueString("Cloning repository: %s", repoURL)
-		err := runCommand(logger, "", "git", "clone", repoURL, repoPath)
-		if err != nil {
+		logger.BlueString("Cloning repository: %s (strategy: %s)", repoURL, strategy)
+		cloneArgs := strategy.cloneArgs(repoURL, repoPath, g.contextMap[constant.ContextValuePullBranch])
+		if err := g.runGitWithRetry(logger, repoURL, "", cloneArgs...); err != nil {
 			return err
 		}
 	}
 
 	// Repository exists; update it.
+	unlock, err := g.lockRepo(logger, repoPath, OperationSync)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := g.updateRepo(logger, repoURL, repoPath); err != nil {
+		return err
+	}
+	if g.prune {
+		return g.pruneLocalBranches(logger, repoURL, repoPath)
+	}
+	return nil
+}
+
+// updateRepo fetches the repository and updates its local branches according to the sync policy.
+func (g *GitlabClient) updateRepo(logger *logWriter.Logger, repoURL, repoPath string) error {
 	logger.MagentaString("Updating repository: %s", repoURL)
 
 	// Fetch all remote changes.
-	if err := runCommand(logger, repoPath, "git", "fetch", "--all"); err != nil {
+	fetchArgs := []string{"fetch", "--all"}
+	if g.prune {
+		fetchArgs = append(fetchArgs, "--prune")
+	}
+	if err := g.runGitWithRetry(logger, repoURL, repoPath, fetchArgs...); err != nil {
 		return err
 	}
+	if g.syncPolicy == SyncPolicyFetch {
+		logger.InfoString("Fetch-only policy: remote-tracking refs updated, working tree untouched")
+		return nil
+	}
 
 	// If the context flag is set to pull only the default branch:
 	if flag, ok := g.contextMap[constant.ContextValuePullDefault]; ok && flag == constant.ContextValueYES {
@@ -141,6 +223,9 @@ func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDi
 		if branchToPull == "" {
 			return fmt.Errorf("pull branch cant be empty")
 		}
+		if g.syncPolicy == SyncPolicyFastForward {
+			return g.fastForwardBranches(logger, repoURL, repoPath, []string{"origin/" + branchToPull})
+		}
 		logger.InfoString("Pulling only branch: %s", branchToPull)
 
 		// Checkout the default branch.
@@ -149,54 +234,35 @@ func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDi
 			return err
 		}
 
-		// Record the current commit as safe state.
-		origHead, err := getCurrentCommit(repoPath)
-		if err != nil {
-			logger.ErrorString("Error getting current commit: %v", err)
-			return err
-		}
-
-		// Check if repository is dirty and stash if needed.
-		dirty, err := isRepoDirty(repoPath)
+		// Stash uncommitted changes, including untracked files, under a Hermes name.
+		stash, err := stashChanges(logger, repoPath, branchToPull)
 		if err != nil {
-			logger.ErrorString("Error checking repository status: %v", err)
+			logger.ErrorString("Error stashing changes: %v", err)
 			return err
 		}
-		stashed := false
-		if dirty {
-			logger.InfoString("Stashing uncommitted changes on branch: %s", branchToPull)
-			if err := runCommand(logger, repoPath, "git", "stash"); err != nil {
-				logger.ErrorString("Error stashing changes: %v", err)
-				return err
-			}
-			stashed = true
-		}
 
 		// Pull the latest changes.
-		if err := runCommand(logger, repoPath, "git", "pull"); err != nil {
+		if err := g.runGitWithRetry(logger, repoURL, repoPath, "pull"); err != nil {
 			logger.ErrorString("Error pulling branch %s: %v", branchToPull, err)
+			if stash != "" {
+				g.keepStash(logger, repoURL, repoPath, stash)
+			}
 			return err
 		}
 
 		// If changes were stashed, attempt to apply them.
-		if stashed {
-			logger.InfoString("Applying stashed changes on branch: %s", branchToPull)
-			if err := runCommand(logger, repoPath, "git", "stash", "apply"); err != nil {
-				logger.ErrorString("Error applying stash on branch %s: %v", branchToPull, err)
-				statusOutput, _ := getGitStatus(repoPath)
-				if strings.Contains(statusOutput, "UU") {
-					logger.ErrorString("Merge conflicts detected after stash apply on branch %s. Aborting pull...", branchToPull)
-					abortPull(repoPath, logger)
-					resetRepo(repoPath, logger, origHead)
-					runCommand(logger, repoPath, "git", "stash", "apply")
-					return fmt.Errorf("conflicts encountered when applying stash on branch %s", branchToPull)
-				}
-			} else {
-				if err := runCommand(logger, repoPath, "git", "stash", "drop"); err != nil {
-					logger.ErrorString("Error dropping stash on branch %s: %v", branchToPull, err)
-				}
+		if stash != "" {
+			if err := g.restoreStash(logger, repoURL, repoPath, stash); err != nil {
+				return err
 			}
 		}
+	} else if g.syncPolicy == SyncPolicyFastForward {
+		branches, err := getRemoteBranches(repoPath)
+		if err != nil {
+			logger.ErrorString("Error getting remote branches: %v", err)
+			return err
+		}
+		return g.fastForwardBranches(logger, repoURL, repoPath, branches)
 	} else {
 		// Otherwise, pull all remote branches.
 		currentBranch, err := getCurrentBranch(repoPath)
@@ -211,23 +277,14 @@ func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDi
 			return err
 		}
 
-		for _, branch := range branches {
-			// Check if repository is dirty.
-			dirty, err := isRepoDirty(repoPath)
-			if err != nil {
-				logger.ErrorString("Error checking repository status: %v", err)
-				continue
-			}
-			stashed := false
-			if dirty {
-				logger.InfoString("Stashing uncommitted changes for branch %s", branch)
-				if err := runCommand(logger, repoPath, "git", "stash"); err != nil {
-					logger.ErrorString("Error stashing changes: %v", err)
-					continue
-				}
-				stashed = true
-			}
+		// Stash uncommitted changes once; they belong to the current branch and are restored there.
+		stash, err := stashChanges(logger, repoPath, currentBranch)
+		if err != nil {
+			logger.ErrorString("Error stashing changes: %v", err)
+			return err
+		}
 
+		for _, branch := range branches {
 			// Convert remote branch name to local branch name (e.g. "origin/feature" -> "feature").
 			parts := strings.Split(branch, "/")
 			localBranch := parts[len(parts)-1]
@@ -238,48 +295,96 @@ func (g *GitlabClient) CloneOrPullRepo(logger *logWriter.Logger, repoURL, baseDi
 				continue
 			}
 
-			origHead, err := getCurrentCommit(repoPath)
-			if err != nil {
-				logger.ErrorString("Error getting current commit: %v", err)
-				continue
-			}
-
 			logger.InfoString("Pulling latest changes on branch: %s", localBranch)
-			if err := runCommand(logger, repoPath, "git", "pull"); err != nil {
+			if err := g.runGitWithRetry(logger, repoURL, repoPath, "pull"); err != nil {
 				logger.ErrorString("Error pulling branch %s: %v", localBranch, err)
 				continue
 			}
-
-			if stashed {
-				logger.InfoString("Applying stashed changes on branch: %s", localBranch)
-				if err := runCommand(logger, repoPath, "git", "stash", "apply"); err != nil {
-					logger.ErrorString("Error applying stash on branch %s: %v", localBranch, err)
-					statusOutput, _ := getGitStatus(repoPath)
-					if strings.Contains(statusOutput, "UU") {
-						logger.ErrorString("Merge conflicts detected after stash apply on branch %s. Aborting pull...", localBranch)
-						abortPull(repoPath, logger)
-						resetRepo(repoPath, logger, origHead)
-						runCommand(logger, repoPath, "git", "stash", "apply")
-						continue
-					}
-				} else {
-					if err := runCommand(logger, repoPath, "git", "stash", "drop"); err != nil {
-						logger.ErrorString("Error dropping stash on branch %s: %v", localBranch, err)
-					}
-				}
-			}
 		}
 
 		// Finally, switch back to the original branch.
 		if err := runCommand(logger, repoPath, "git", "checkout", currentBranch); err != nil {
 			logger.ErrorString("Error checking out branch %s: %v", currentBranch, err)
+			if stash != "" {
+				g.keepStash(logger, repoURL, repoPath, stash)
+			}
 			return err
 		}
+		if stash != "" {
+			if err := g.restoreStash(logger, repoURL, repoPath, stash); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// fastForwardBranches fast-forwards the local counterparts of the given "origin/<branch>" refs
+// without checking them out. Missing local branches are created; branches with local commits that
+// are not on the remote are reported as diverged and left untouched.
+func (g *GitlabClient) fastForwardBranches(logger *logWriter.Logger, repoURL, repoPath string, remoteBranches []string) error {
+	currentBranch, err := getCurrentBranch(repoPath)
+	if err != nil {
+		logger.ErrorString("Error getting current branch: %v", err)
+		return err
+	}
+
+	for _, remoteBranch := range remoteBranches {
+		localBranch, ok := strings.CutPrefix(remoteBranch, "origin/")
+		if !ok {
+			continue
+		}
+
+		if !branchExists(repoPath, localBranch) {
+			logger.InfoString("Creating local branch %s from %s", localBranch, remoteBranch)
+			if err := runCommand(logger, repoPath, "git", "branch", "--track", localBranch, remoteBranch); err != nil {
+				logger.ErrorString("Error creating branch %s: %v", localBranch, err)
+			}
+			continue
+		}
+
+		// Nothing to do when the local branch already contains the remote one (up to date or ahead).
+		if isAncestor(repoPath, remoteBranch, localBranch) {
+			continue
+		}
+		if !isAncestor(repoPath, localBranch, remoteBranch) {
+			logger.RedString("Branch %s has diverged from %s; leaving it untouched", localBranch, remoteBranch)
+			g.updateResult(repoURL, func(r *RepoResult) { r.Diverged = append(r.Diverged, localBranch) })
+			continue
+		}
+
+		logger.InfoString("Fast-forwarding branch: %s", localBranch)
+		if localBranch == currentBranch {
+			// A checked-out branch cannot be updated by fetch; merge refuses anything but a fast-forward
+			// and leaves uncommitted changes in place.
+			err = runCommand(logger, repoPath, "git", "merge", "--ff-only", remoteBranch)
+		} else {
+			err = g.runGitWithRetry(logger, repoURL, repoPath, "fetch", "origin", localBranch+":"+localBranch)
+		}
+		if err != nil {
+			logger.ErrorString("Error fast-forwarding branch %s: %v", localBranch, err)
+		}
+	}
+	return nil
+}
+
+// cloneOrUpdateMirror clones a bare mirror of the repository, or updates an existing one with
+// "git remote update". Mirrors have no working tree, so there is nothing to check out or pull.
+func (g *GitlabClient) cloneOrUpdateMirror(logger *logWriter.Logger, repoURL, mirrorPath string, strategy CloneStrategy) error {
+	if _, err := os.Stat(mirrorPath); os.IsNotExist(err) {
+		logger.BlueString("Mirroring repository: %s", repoURL)
+		return g.runGitWithRetry(logger, repoURL, "", strategy.cloneArgs(repoURL, mirrorPath, "")...)
+	}
+	logger.MagentaString("Updating mirror: %s", repoURL)
+	unlock, err := g.lockRepo(logger, mirrorPath, OperationSync)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return g.runGitWithRetry(logger, repoURL, mirrorPath, "remote", "update", "--prune")
+}
+
 // getGitStatus runs "git status --porcelain" and returns its output.
 func getGitStatus(repoPath string) (string, error) {
 	cmd := exec.Command("git", "status", "--porcelain")
@@ -302,14 +407,6 @@ func getCurrentCommit(repoPath string) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
-// abortPull attempts to abort any merge in progress.
-func abortPull(repoPath string, logger *logWriter.Logger) {
-	logger.InfoString("Aborting merge/pull in repository.")
-	if err := runCommand(logger, repoPath, "git", "merge", "--abort"); err != nil {
-		logger.ErrorString("Error aborting merge: %v", err)
-	}
-}
-
 // resetRepo resets the repository to the given commit.
 func resetRepo(repoPath string, logger *logWriter.Logger, commit string) {
 	logger.InfoString("Resetting repository to commit: %s", commit)
@@ -318,6 +415,13 @@ func resetRepo(repoPath string, logger *logWriter.Logger, commit string) {
 	}
 }
 
+// isAncestor reports whether commit ancestor is reachable from commit descendant.
+func isAncestor(repoPath, ancestor, descendant string) bool {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", ancestor, descendant)
+	cmd.Dir = repoPath
+	return cmd.Run() == nil
+}
+
 // getRemoteBranches runs "git branch -r" and returns a slice of remote branch names.
 func getRemoteBranches(repoPath string) ([]string, error) {
 	cmd := exec.Command("git", "branch", "-r")
@@ -338,6 +442,28 @@ func getRemoteBranches(repoPath string) ([]string, error) {
 	return branches, nil
 }
 
+// RemoteBranches returns the branches of origin in the repositories under baseDir, sorted and
+// without duplicates. Only the remote-tracking refs are read; nothing is fetched.
+func RemoteBranches(baseDir string) ([]string, error) {
+	repos, err := findRepositories(baseDir, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, repo := range repos {
+		branches, err := getRemoteBranches(repo)
+		if err != nil {
+			continue
+		}
+		for _, branch := range branches {
+			if name, ok := strings.CutPrefix(branch, "origin/"); ok {
+				names[name] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(names)), nil
+}
+
 // getCurrentBranch returns the current branch name.
 func getCurrentBranch(repoPath string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
@@ -364,9 +490,14 @@ func isRepoDirty(repoPath string) (bool, error) {
 	return strings.TrimSpace(string(out)) != "", nil
 }
 
+// runGit runs a git command that may talk to the remote, using the client's credential environment.
+func (g *GitlabClient) runGit(logger *logWriter.Logger, dir string, args ...string) error {
+	return runCommandEnv(logger, g.gitEnv, dir, "git", args...)
+}
+
 // pushBranch pushes the current branch to GitLab.
-func pushBranch(logger *logWriter.Logger, repoDir string) error {
-	return runCommand(logger, repoDir, "git", "push", "-u", "origin", "HEAD")
+func (g *GitlabClient) pushBranch(logger *logWriter.Logger, repoDir string) error {
+	return g.runGitWithRetry(logger, repoDir, repoDir, "push", "-u", "origin", "HEAD")
 }
 
 // CreateBranch creates a new branch and switches to it.
@@ -400,73 +531,63 @@ func branchExists(repoDir, branch string) bool {
 	return strings.TrimSpace(string(out)) != ""
 }
 
-// isValidRepo checks whether the repository’s relative path matches the include/exclude criteria.
-func isValidRepo(repoPath string, includePatterns, excludePatterns []string) bool {
-	// Use path.Match for wildcard matching.
-	for _, includePattern := range includePatterns {
-		matched, err := path.Match(includePattern, repoPath)
+// projectPathFromURL extracts the project path (e.g. "s.hatami/test") from a repository URL.
+// It handles SSH, scp-like and HTTP(S) URL formats:
+//   - "ssh://git@git.*.app:2222/s.hatami/test.git"
+//   - "git@git.*.app:s.hatami/test.git"
+//   - "https://git.*.app/s.hatami/test.git"
+func projectPathFromURL(repoURL string) (string, error) {
+	var projectPath string
+	if strings.Contains(repoURL, "://") {
+		u, err := url.Parse(repoURL)
 		if err != nil {
-			continue
+			return "", fmt.Errorf("error parsing remote URL: %v", err)
 		}
-		if matched {
-			// If an exclude pattern matches, skip this repository.
-			for _, excludePattern := range excludePatterns {
-				matched, err := path.Match(excludePattern, repoPath)
-				if err != nil {
-					continue
-				}
-				if matched {
-					return false
-				}
-			}
-			return true
+		projectPath = u.Path
+	} else {
+		parts := strings.SplitN(repoURL, ":", 2)
+		if len(parts) < 2 {
+			return "", fmt.Errorf("cannot parse remote URL: %s", repoURL)
 		}
+		projectPath = parts[1]
+	}
+	projectPath = strings.TrimSuffix(strings.Trim(projectPath, "/"), ".git")
+	if projectPath == "" {
+		return "", fmt.Errorf("cannot parse remote URL: %s", repoURL)
 	}
-	return false
+	return projectPath, nil
 }
 
-// getProjectIDFromRepo retrieves the project ID by parsing the remote URL.
-// It handles both SSH URL formats:
-//   - "ssh://git@git.*.app:2222/s.hatami/test.git"
-//   - "git@git.*.app:s.hatami/test.git"
-func getProjectIDFromRepo(repoDir string, client *gitlab.Client) (interface{}, error) {
-	// Get the remote URL using git config.
+// ProjectPath returns the path of the repository's project on GitLab, parsed from its origin URL.
+// See projectPathFromURL for the supported URL formats.
+func ProjectPath(repoDir string) (string, error) {
 	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
 	cmd.Dir = repoDir
 	out, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("error getting remote URL: %v", err)
+		return "", fmt.Errorf("error getting remote URL: %v", err)
 	}
-	remoteURL := strings.TrimSpace(string(out))
+	return projectPathFromURL(strings.TrimSpace(string(out)))
+}
 
-	// Parse the remote URL to extract the project path.
-	var projectPath string
-	if strings.HasPrefix(remoteURL, "ssh://") {
-		u, err := url.Parse(remoteURL)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing remote URL: %v", err)
-		}
-		projectPath = strings.TrimPrefix(u.Path, "/")
-		projectPath = strings.TrimSuffix(projectPath, ".git")
-	} else {
-		parts := strings.Split(remoteURL, ":")
-		if len(parts) < 2 {
-			return nil, fmt.Errorf("cannot parse remote URL: %s", remoteURL)
-		}
-		projectPath = strings.TrimSuffix(parts[1], ".git")
+// getProjectIDFromRepo retrieves the project ID by parsing the remote URL.
+func (g *GitlabClient) getProjectIDFromRepo(repoDir string, client *gitlab.Client) (interface{}, error) {
+	projectPath, err := ProjectPath(repoDir)
+	if err != nil {
+		return nil, err
 	}
-	project, _, err := client.Projects.GetProject(projectPath, nil)
+	project, err := g.getProject(client, projectPath)
 	if err != nil {
-		return nil, fmt.Errorf("project not found for remote URL: %s; error: %v", remoteURL, err)
+		return nil, fmt.Errorf("project %s not found: %v", projectPath, err)
 	}
 	return project.ID, nil
 }
 
 // createMergeRequest creates a merge request on GitLab.
-func createMergeRequest(logger *logWriter.Logger, gitlabClient *gitlab.Client, projectID interface{}, targetBranch, branchName, titleMsg, descriptionMsg string) error {
-	user, _, err := gitlabClient.Users.CurrentUser()
+func (g *GitlabClient) createMergeRequest(logger *logWriter.Logger, gitlabClient *gitlab.Client, projectID interface{}, targetBranch, branchName, titleMsg, descriptionMsg string) error {
+	user, err := g.currentUser(gitlabClient)
 	if err != nil {
-		logger.ErrorString("Failed to fetch current user: %v", err)
+		logger.ErrorString("%v", err)
 		return err
 	}
 
@@ -510,51 +631,51 @@ func CommitChanges(logger *logWriter.Logger, repoDir, commitMsg string) error {
 	return nil
 }
 
-// countMatchingRepositories counts the total number of Git repositories
-// that match the include/exclude patterns.
-func countMatchingRepositories(baseDir string, includePatterns, excludePatterns []string) (int, error) {
-	var count int
+// findRepositories walks baseDir and returns the paths of all Git repositories
+// that match the include/exclude patterns. It does not descend into repositories.
+func findRepositories(baseDir string, includePatterns, excludePatterns []string) ([]string, error) {
+	var repos []string
 	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		// Process only directories.
 		if !info.IsDir() {
 			return nil
 		}
-
-		// Check if the directory is a Git repository (by the existence of ".git").
-		gitPath := filepath.Join(path, ".git")
-		if _, err := os.Stat(gitPath); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath.Join(path, ".git")); os.IsNotExist(err) {
 			return nil // not a git repository; continue walking
 		}
-
-		// Compute a relative path for pattern matching.
 		relPath, err := filepath.Rel(baseDir, path)
 		if err != nil {
 			relPath = path // fallback to full path
 		}
-
-		// Validate repository against include/exclude rules.
-		if isValidRepo(relPath, includePatterns, excludePatterns) {
-			count++
+		if repoSelected(relPath, includePatterns, excludePatterns) {
+			repos = append(repos, path)
 		}
-		return nil
+		return filepath.SkipDir
 	})
-	return count, err
+	return repos, err
 }
 
 // executeCommands splits the command string and executes each command in the repository directory.
+// Failing commands are logged and do not stop the commands after them.
 func executeCommands(logger *logWriter.Logger, repoDir, commandStr string) error {
-	// Split the command string by semicolon to separate commands.
-	commands := strings.Split(commandStr, ";")
+	_ = runCommands(logger, repoDir, commandStr)
+	return nil
+}
+
+// runCommands is like executeCommands but returns the errors of the commands that failed.
+func runCommands(logger *logWriter.Logger, repoDir, commandStr string) error {
+	var errs []error
+	// Commands are separated by semicolons or written on separate lines.
+	commands := strings.FieldsFunc(commandStr, func(r rune) bool { return r == ';' || r == '\n' })
 	for _, cmdStr := range commands {
 		cmdStr = strings.TrimSpace(cmdStr)
 		if cmdStr == "" {
 			continue
 		}
 		// Split the command string by spaces to separate the command from its arguments.
-		parts := strings.Split(cmdStr, " ")
+		parts := strings.Fields(cmdStr)
 		cmdName := parts[0]
 		cmdArgs := parts[1:]
 
@@ -563,9 +684,10 @@ func executeCommands(logger *logWriter.Logger, repoDir, commandStr string) error
 		if err := runCommand(logger, repoDir, cmdName, cmdArgs...); err != nil {
 			logger.ErrorString("Error running command '%s' in %s: %v", cmdStr, repoDir, err)
 			// Continue with the next command, if any.
+			errs = append(errs, fmt.Errorf("%s: %w", cmdStr, err))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // checkoutAndResetBranch checks out to 'develop' or 'main' if not already on either.
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"github.com/sinaw369/Hermes/internal/state"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Operations recorded in lock files.
+const (
+	OperationSync         = "sync"
+	OperationPull         = "pull"
+	OperationMerge        = "merge"
+	OperationPrune        = "prune"
+	OperationStashRestore = "stash restore"
+	OperationCommand      = "command"
+)
+
+// The workspace lock is kept in a ".hermes" directory of the workspace; repository locks are kept
+// in the git directory of each repository, so they also guard runs on overlapping directories.
+const (
+	workspaceLockFile = ".hermes/workspace.lock"
+	repoLockFile      = "hermes.lock"
+	lockPollInterval  = 500 * time.Millisecond
+)
+
+// WorkspaceLockPath returns the path of the lock file of the workspace directory.
+func WorkspaceLockPath(baseDir string) string {
+	return filepath.Join(baseDir, filepath.FromSlash(workspaceLockFile))
+}
+
+// RepoLockPath returns the path of the lock file of the repository, which is either a working copy
+// or a bare mirror.
+func RepoLockPath(repoPath string) string {
+	if info, err := os.Stat(filepath.Join(repoPath, ".git")); err == nil && info.IsDir() {
+		return filepath.Join(repoPath, ".git", repoLockFile)
+	}
+	return filepath.Join(repoPath, repoLockFile)
+}
+
+// OnWorkspaceLocked sets a function called once the client holds the workspace lock, before it
+// starts working on the repositories.
+func (g *GitlabClient) OnWorkspaceLocked(fn func()) {
+	g.onWorkspaceLocked = fn
+}
+
+// lockWorkspace takes the workspace lock of baseDir for the operation and returns a function releasing it.
+func (g *GitlabClient) lockWorkspace(baseDir, operation string) (func(), error) {
+	unlock, err := g.acquireLock(g.logWriter, WorkspaceLockPath(baseDir), operation)
+	if err == nil && g.onWorkspaceLocked != nil {
+		g.onWorkspaceLocked()
+	}
+	return unlock, err
+}
+
+// lockRepo takes the lock of a single repository if repository locks are enabled.
+// The returned function releases it and is never nil.
+func (g *GitlabClient) lockRepo(logger *logWriter.Logger, repoPath, operation string) (func(), error) {
+	if !g.lockRepositories {
+		return func() {}, nil
+	}
+	return g.acquireLock(logger, RepoLockPath(repoPath), operation)
+}
+
+// acquireLock takes the lock file at path. If another process holds it, it fails right away or
+// polls until the configured lock wait has passed.
+func (g *GitlabClient) acquireLock(logger *logWriter.Logger, path, operation string) (func(), error) {
+	deadline := time.Now().Add(g.lockWait)
+	waiting := false
+	for {
+		lock, err := state.TryLock(path, operation)
+		if err == nil {
+			return func() {
+				if err := lock.Release(); err != nil {
+					logger.ErrorString("Error releasing lock %s: %v", path, err)
+				}
+			}, nil
+		}
+
+		var locked *state.LockedError
+		if !errors.As(err, &locked) {
+			return nil, err
+		}
+		if g.lockWait <= 0 {
+			return nil, fmt.Errorf("%v; try again later, set LOCK_WAIT to wait for it, or run 'hermes unlock' if that process crashed", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("gave up after waiting %s: %v", g.lockWait, err)
+		}
+		if !waiting {
+			logger.YellowString("Waiting up to %s for %s held by %s", g.lockWait, path, locked.Holder)
+			waiting = true
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// LockFiles returns the workspace lock and the repository locks, including those of mirrors,
+// that currently exist under baseDir.
+func LockFiles(baseDir string) ([]string, error) {
+	var locks []string
+	if _, err := os.Stat(WorkspaceLockPath(baseDir)); err == nil {
+		locks = append(locks, WorkspaceLockPath(baseDir))
+	}
+	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".hermes" {
+			return filepath.SkipDir
+		}
+		_, gitErr := os.Stat(filepath.Join(path, ".git"))
+		_, headErr := os.Stat(filepath.Join(path, "HEAD"))
+		isMirror := filepath.Ext(path) == ".git" && headErr == nil
+		if gitErr != nil && !isMirror {
+			return nil // not a repository; continue walking
+		}
+		if _, err := os.Stat(RepoLockPath(path)); err == nil {
+			locks = append(locks, RepoLockPath(path))
+		}
+		return filepath.SkipDir
+	})
+	return locks, err
+}
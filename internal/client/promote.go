@@ -0,0 +1,101 @@
+package client
+
+import (
+	"fmt"
+	"gitlab.com/gitlab-org/api/client-go"
+	"strings"
+)
+
+// Promotion is a repository whose source branch has commits the target branch lacks.
+type Promotion struct {
+	Repository string
+	Commits    []Commit
+	Err        error // the commits could not be listed
+}
+
+// PromotionResult tells which merge request a promotion opened or reused.
+type PromotionResult struct {
+	IID    int
+	WebURL string
+	Reused bool
+}
+
+// Repositories returns the repositories the client works on: the ones set with SetRepositories,
+// or the ones under the directory of the context matching its include/exclude patterns.
+func (g *GitlabClient) Repositories() ([]string, error) {
+	return g.selectedRepositories()
+}
+
+// PendingPromotions returns the repositories where origin/<from> has commits that origin/<to> lacks,
+// and the ones whose commits could not be listed. Repositories without pending commits are left out.
+func PendingPromotions(repos []string, from, to string) []Promotion {
+	var promotions []Promotion
+	for _, repoPath := range repos {
+		commits, err := DiffCommits(repoPath, to, from)
+		if err != nil || len(commits) > 0 {
+			promotions = append(promotions, Promotion{Repository: repoPath, Commits: commits, Err: err})
+		}
+	}
+	return promotions
+}
+
+// PromotionDescription lists the commits of a promotion for the description of its merge request.
+func PromotionDescription(from, to string, commits []Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Promotes %d commit(s) of `%s` to `%s`:\n\n", len(commits), from, to)
+	for _, commit := range commits {
+		fmt.Fprintf(&b, "- %s %s (%s, %s)\n", commit.Hash[:min(len(commit.Hash), 8)], commit.Subject, commit.Author, commit.Date.Format("2006-01-02"))
+	}
+	return b.String()
+}
+
+// Promote opens a merge request from the from branch into the to branch of the repository's project,
+// listing the commits in its description. An open merge request between the two branches is reused:
+// its description is updated with the current commits.
+func (g *GitlabClient) Promote(promotion Promotion, from, to, title string) (PromotionResult, error) {
+	gitlabClient, err := g.createGitLabClient()
+	if err != nil {
+		return PromotionResult{}, err
+	}
+	projectID, err := g.getProjectIDFromRepo(promotion.Repository, gitlabClient)
+	if err != nil {
+		return PromotionResult{}, err
+	}
+	description := PromotionDescription(from, to, promotion.Commits)
+
+	open, _, err := gitlabClient.MergeRequests.ListProjectMergeRequests(projectID, &gitlab.ListProjectMergeRequestsOptions{
+		State:        gitlab.Ptr("opened"),
+		SourceBranch: gitlab.Ptr(from),
+		TargetBranch: gitlab.Ptr(to),
+	})
+	if err != nil {
+		return PromotionResult{}, fmt.Errorf("failed to list merge requests: %v", err)
+	}
+	if len(open) > 0 {
+		mr, _, err := gitlabClient.MergeRequests.UpdateMergeRequest(projectID, open[0].IID, &gitlab.UpdateMergeRequestOptions{
+			Description: gitlab.Ptr(description),
+		})
+		if err != nil {
+			return PromotionResult{}, fmt.Errorf("failed to update merge request !%d: %v", open[0].IID, err)
+		}
+		return PromotionResult{IID: mr.IID, WebURL: mr.WebURL, Reused: true}, nil
+	}
+
+	user, err := g.currentUser(gitlabClient)
+	if err != nil {
+		return PromotionResult{}, err
+	}
+	// Unlike the merge requests of merge campaigns, the source branch is long-lived and must be kept.
+	mr, _, err := gitlabClient.MergeRequests.CreateMergeRequest(projectID, &gitlab.CreateMergeRequestOptions{
+		SourceBranch:       gitlab.Ptr(from),
+		TargetBranch:       gitlab.Ptr(to),
+		Title:              gitlab.Ptr(title),
+		Description:        gitlab.Ptr(description),
+		AssigneeID:         &user.ID,
+		RemoveSourceBranch: gitlab.Ptr(false),
+	})
+	if err != nil {
+		return PromotionResult{}, fmt.Errorf("failed to create merge request: %v", err)
+	}
+	return PromotionResult{IID: mr.IID, WebURL: mr.WebURL}, nil
+}
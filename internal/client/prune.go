@@ -0,0 +1,156 @@
+package client
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InitPruneFromDir prunes stale remote-tracking refs and local branches in every repository
+// under the configured directory that matches the include/exclude patterns.
+func (g *GitlabClient) InitPruneFromDir() {
+	unlock, err := g.lockWorkspace(g.getBaseDir(constant.TargetDir), OperationPrune)
+	if err != nil {
+		g.logWriter.ErrorString("%v", err)
+		return
+	}
+	defer unlock()
+
+	repos, err := g.selectedRepositories()
+	if err != nil {
+		g.logWriter.ErrorString("Error finding repositories: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, g.concurrencyOr(10)) // Limit to 10 concurrent operations by default
+	for _, repoPath := range repos {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(repoPath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			g.logWriter.BlueString("Pruning repository: %s", repoPath)
+			unlockRepo, err := g.lockRepo(g.logWriter, repoPath, OperationPrune)
+			if err == nil {
+				defer unlockRepo()
+				err = g.runGitWithRetry(g.logWriter, repoPath, repoPath, "fetch", "--all", "--prune")
+			}
+			if err == nil {
+				err = g.pruneLocalBranches(g.logWriter, repoPath, repoPath)
+			}
+			g.updateResult(repoPath, func(r *RepoResult) { r.Err = err })
+		}(repoPath)
+	}
+	wg.Wait()
+
+	g.logPruneReport()
+}
+
+// pruneLocalBranches deletes local branches that track a remote branch and are merged into the
+// default branch or whose upstream is gone. Branches without an upstream, such as one just created
+// with "git switch -c", branches with commits that exist on no remote, the current branch and the
+// default branch are never deleted. Remote-tracking refs are expected to be pruned already.
+func (g *GitlabClient) pruneLocalBranches(logger *logWriter.Logger, repoKey, repoPath string) error {
+	defaultBranch, err := g.getDefaultBranch(logger, repoPath)
+	if err != nil {
+		logger.ErrorString("Error getting default branch: %v", err)
+		return err
+	}
+	currentBranch, err := getCurrentBranch(repoPath)
+	if err != nil {
+		logger.ErrorString("Error getting current branch: %v", err)
+		return err
+	}
+
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short)%00%(upstream)%00%(upstream:track)", "refs/heads")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("error listing local branches: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		branch, upstream, track := fields[0], fields[1], fields[2]
+		if branch == "" || upstream == "" || branch == currentBranch || branch == defaultBranch {
+			continue
+		}
+
+		merged := isAncestor(repoPath, branch, "origin/"+defaultBranch)
+		gone := track == "[gone]"
+		if !merged && !gone {
+			continue
+		}
+
+		unpushed, err := countUnpushedCommits(repoPath, branch)
+		if err != nil {
+			logger.ErrorString("Error counting unpushed commits on %s: %v", branch, err)
+			continue
+		}
+		if unpushed > 0 {
+			logger.YellowString("Keeping branch %s: %d unpushed commit(s)", branch, unpushed)
+			g.updateResult(repoKey, func(r *RepoResult) { r.Protected = append(r.Protected, branch) })
+			continue
+		}
+
+		// Safe to force-delete: every commit on the branch is reachable from a remote ref.
+		if err := runCommand(logger, repoPath, "git", "branch", "-D", branch); err != nil {
+			logger.ErrorString("Error deleting branch %s: %v", branch, err)
+			continue
+		}
+		g.updateResult(repoKey, func(r *RepoResult) { r.Pruned = append(r.Pruned, branch) })
+	}
+	return nil
+}
+
+// getDefaultBranch returns the default branch of origin, as recorded by refs/remotes/origin/HEAD.
+// If the symbolic ref is missing it is queried from the remote once.
+func (g *GitlabClient) getDefaultBranch(logger *logWriter.Logger, repoPath string) (string, error) {
+	symbolicRef := func() (string, error) {
+		cmd := exec.Command("git", "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+		cmd.Dir = repoPath
+		out, err := cmd.Output()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(strings.TrimSpace(string(out)), "origin/"), nil
+	}
+
+	if branch, err := symbolicRef(); err == nil {
+		return branch, nil
+	}
+	if err := g.runGit(logger, repoPath, "remote", "set-head", "origin", "--auto"); err != nil {
+		return "", err
+	}
+	return symbolicRef()
+}
+
+// countUnpushedCommits returns the number of commits on branch that are not on any remote-tracking ref.
+func countUnpushedCommits(repoPath, branch string) (int, error) {
+	cmd := exec.Command("git", "rev-list", "--count", branch, "--not", "--remotes")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// logPruneReport lists repositories without stale branches, followed by the usual per-repository results.
+func (g *GitlabClient) logPruneReport() {
+	for _, r := range g.Results() {
+		if r.Err == nil && len(r.Pruned) == 0 && len(r.Protected) == 0 {
+			g.logWriter.InfoString("%s: nothing to prune", r.Repository)
+		}
+	}
+	g.logResults()
+}
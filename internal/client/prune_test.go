@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"github.com/sinaw369/Hermes/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testGit runs git in dir for a test and returns its trimmed output.
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=hermes", "GIT_AUTHOR_EMAIL=hermes@example.com",
+		"GIT_COMMITTER_NAME=hermes", "GIT_COMMITTER_EMAIL=hermes@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestPruneLocalBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	remote, repo := filepath.Join(dir, "remote.git"), filepath.Join(dir, "repo")
+	testGit(t, dir, "init", "--bare", "--initial-branch=main", remote)
+	testGit(t, dir, "clone", remote, repo)
+	testGit(t, repo, "commit", "--allow-empty", "-m", "initial")
+	testGit(t, repo, "push", "-u", "origin", "main")
+
+	// merged: pushed and merged into main.
+	testGit(t, repo, "switch", "-c", "merged")
+	testGit(t, repo, "commit", "--allow-empty", "-m", "merged work")
+	testGit(t, repo, "push", "-u", "origin", "merged")
+	testGit(t, repo, "switch", "main")
+	testGit(t, repo, "merge", "--ff-only", "merged")
+	testGit(t, repo, "push", "origin", "main")
+	// gone: pushed, merged into main, then deleted on the remote.
+	testGit(t, repo, "switch", "-c", "gone")
+	testGit(t, repo, "commit", "--allow-empty", "-m", "gone work")
+	testGit(t, repo, "push", "-u", "origin", "gone")
+	testGit(t, repo, "push", "origin", "gone:main")
+	testGit(t, repo, "push", "origin", "--delete", "gone")
+	// unpushed: upstream gone, with a commit that is on no remote.
+	testGit(t, repo, "switch", "-c", "unpushed", "main")
+	testGit(t, repo, "push", "-u", "origin", "unpushed")
+	testGit(t, repo, "commit", "--allow-empty", "-m", "local work")
+	testGit(t, repo, "push", "origin", "--delete", "unpushed")
+	// local: just created off main, without upstream.
+	testGit(t, repo, "switch", "-c", "local", "main")
+	// open: pushed and not merged.
+	testGit(t, repo, "switch", "-c", "open", "main")
+	testGit(t, repo, "commit", "--allow-empty", "-m", "open work")
+	testGit(t, repo, "push", "-u", "origin", "open")
+	testGit(t, repo, "switch", "main")
+	testGit(t, repo, "fetch", "--prune")
+	testGit(t, repo, "remote", "set-head", "origin", "main")
+
+	g, err := NewCLIGitClient(context.Background(), nil, &config.Config{GitRetryAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.pruneLocalBranches(g.logWriter, repo, repo); err != nil {
+		t.Fatal(err)
+	}
+
+	branches := strings.Fields(testGit(t, repo, "for-each-ref", "--format=%(refname:short)", "refs/heads"))
+	sort.Strings(branches)
+	if want := []string{"local", "main", "open", "unpushed"}; !reflect.DeepEqual(branches, want) {
+		t.Errorf("branches after pruning = %v, want %v", branches, want)
+	}
+	result := g.Results()[0]
+	sort.Strings(result.Pruned)
+	if want := []string{"gone", "merged"}; !reflect.DeepEqual(result.Pruned, want) {
+		t.Errorf("pruned = %v, want %v", result.Pruned, want)
+	}
+	if want := []string{"unpushed"}; !reflect.DeepEqual(result.Protected, want) {
+		t.Errorf("protected = %v, want %v", result.Protected, want)
+	}
+}
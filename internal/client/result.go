@@ -0,0 +1,68 @@
+package client
+
+import (
+	"sort"
+	"strings"
+)
+
+// RepoResult describes the outcome of syncing a single repository.
+type RepoResult struct {
+	Repository  string
+	Diverged    []string // local branches left untouched because they diverged from their remote branch
+	Pruned      []string // local branches deleted by pruning
+	Protected   []string // stale local branches kept because they have unpushed commits
+	KeptStashes []string // "<ref> <name>" of stashes that could not be restored
+	Retries     []string // git network commands that were retried, with the error that caused it
+	Err         error
+}
+
+// updateResult applies fn to the result entry of the repository, creating it if needed.
+func (g *GitlabClient) updateResult(repository string, fn func(r *RepoResult)) {
+	g.resultsMu.Lock()
+	defer g.resultsMu.Unlock()
+	if g.results == nil {
+		g.results = make(map[string]*RepoResult)
+	}
+	r, ok := g.results[repository]
+	if !ok {
+		r = &RepoResult{Repository: repository}
+		g.results[repository] = r
+	}
+	fn(r)
+}
+
+// Results returns the per-repository results collected so far, sorted by repository.
+func (g *GitlabClient) Results() []RepoResult {
+	g.resultsMu.Lock()
+	defer g.resultsMu.Unlock()
+	results := make([]RepoResult, 0, len(g.results))
+	for _, r := range g.results {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Repository < results[j].Repository })
+	return results
+}
+
+// logResults writes a summary of repositories that failed or need attention.
+func (g *GitlabClient) logResults() {
+	for _, r := range g.Results() {
+		if r.Err != nil {
+			g.logWriter.RedString("%s: failed: %v", r.Repository, r.Err)
+		}
+		if len(r.Diverged) > 0 {
+			g.logWriter.YellowString("%s: diverged branches not updated: %s", r.Repository, strings.Join(r.Diverged, ", "))
+		}
+		if len(r.Pruned) > 0 {
+			g.logWriter.GreenString("%s: pruned %s", r.Repository, strings.Join(r.Pruned, ", "))
+		}
+		if len(r.Protected) > 0 {
+			g.logWriter.YellowString("%s: kept (unpushed commits) %s", r.Repository, strings.Join(r.Protected, ", "))
+		}
+		if len(r.Retries) > 0 {
+			g.logWriter.YellowString("%s: retried %s", r.Repository, strings.Join(r.Retries, "; "))
+		}
+		if len(r.KeptStashes) > 0 {
+			g.logWriter.YellowString("%s: uncommitted changes kept in %s", r.Repository, strings.Join(r.KeptStashes, ", "))
+		}
+	}
+}
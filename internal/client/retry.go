@@ -0,0 +1,126 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"strings"
+	"time"
+)
+
+// gitRetryMaxWait caps the backoff between attempts of a git network command.
+const gitRetryMaxWait = time.Minute
+
+// Stderr fragments of git errors that retrying cannot fix, such as rejected credentials or
+// a missing repository. They are checked before the retriable ones.
+var permanentGitErrors = []string{
+	"authentication failed",
+	"permission denied",
+	"access denied",
+	"could not read username",
+	"could not read password",
+	"host key verification failed",
+	"repository not found",
+	"does not appear to be a git repository",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+	"the requested url returned error: 404",
+	"conflict (",
+	"merge conflict",
+	"not possible to fast-forward",
+	"would be overwritten",
+	"[rejected]",
+	"couldn't find remote ref",
+}
+
+// Stderr fragments of transient network and server errors.
+var retriableGitErrors = []string{
+	"connection reset",
+	"connection refused",
+	"connection timed out",
+	"operation timed out",
+	"connection closed by",
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"network is unreachable",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"unexpected disconnect",
+	"rpc failed",
+	"kex_exchange_identification",
+	"ssh_exchange_identification",
+	"broken pipe",
+	"tls connection was non-properly terminated",
+	"gnutls_handshake",
+	"the requested url returned error: 429",
+	"the requested url returned error: 5",
+}
+
+// classifyStderr returns the first stderr line matching one of the fragments.
+func classifyStderr(stderr string, fragments []string) (string, bool) {
+	for _, line := range strings.Split(stderr, "\n") {
+		lower := strings.ToLower(line)
+		for _, fragment := range fragments {
+			if strings.Contains(lower, fragment) {
+				return strings.TrimSpace(line), true
+			}
+		}
+	}
+	return "", false
+}
+
+// stderrReason picks the line of a command's stderr that best explains the failure: a known git
+// error, else the first "fatal:" or "error:" line, else the last line.
+func stderrReason(stderr string) string {
+	if line, ok := classifyStderr(stderr, permanentGitErrors); ok {
+		return line
+	}
+	if line, ok := classifyStderr(stderr, retriableGitErrors); ok {
+		return line
+	}
+	if line, ok := classifyStderr(stderr, []string{"fatal:", "error:"}); ok {
+		return line
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// retriableGitError reports whether a failed git command is worth retrying, and the stderr
+// line that decided it. Errors that match neither list are treated as permanent.
+func retriableGitError(err error) (bool, string) {
+	var cmdErr *commandError
+	if !errors.As(err, &cmdErr) {
+		return false, "" // the command could not be started at all
+	}
+	if line, ok := classifyStderr(cmdErr.stderr, permanentGitErrors); ok {
+		return false, line
+	}
+	if line, ok := classifyStderr(cmdErr.stderr, retriableGitErrors); ok {
+		return true, line
+	}
+	return false, ""
+}
+
+// runGitWithRetry runs a git network command and retries it with exponential backoff while it fails
+// with a retriable error. Every retry is recorded in the result of the repository.
+func (g *GitlabClient) runGitWithRetry(logger *logWriter.Logger, repoKey, dir string, args ...string) error {
+	wait := g.gitRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := g.runGit(logger, dir, args...)
+		if err == nil || attempt >= g.gitRetryAttempts {
+			return err
+		}
+		retriable, reason := retriableGitError(err)
+		if !retriable {
+			return err
+		}
+
+		logger.YellowString("git %s failed (%s); retrying in %s (attempt %d of %d)",
+			args[0], reason, wait.Round(time.Millisecond), attempt+1, g.gitRetryAttempts)
+		g.updateResult(repoKey, func(r *RepoResult) {
+			r.Retries = append(r.Retries, fmt.Sprintf("git %s attempt %d: %s", args[0], attempt+1, reason))
+		})
+		time.Sleep(wait/2 + jitter(wait/2))
+		wait = min(wait*2, gitRetryMaxWait)
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRetriableGitError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retriable bool
+		line      string
+	}{
+		{
+			name: "not started",
+			err:  errors.New("exec: \"git\": executable file not found in $PATH"),
+		},
+		{
+			name:      "connection reset",
+			err:       &commandError{stderr: "fatal: unable to access 'https://gitlab.example.com/grp/api.git/': Connection reset by peer"},
+			retriable: true,
+			line:      "fatal: unable to access 'https://gitlab.example.com/grp/api.git/': Connection reset by peer",
+		},
+		{
+			name:      "repository named after conflicts",
+			err:       &commandError{stderr: "fatal: unable to access 'https://gitlab.example.com/grp/conflict-resolver.git/': Could not resolve host: gitlab.example.com"},
+			retriable: true,
+			line:      "fatal: unable to access 'https://gitlab.example.com/grp/conflict-resolver.git/': Could not resolve host: gitlab.example.com",
+		},
+		{
+			name:      "HTTP 409 from a proxy",
+			err:       &commandError{stderr: "error: RPC failed; HTTP 409 Conflict\nfatal: the remote end hung up unexpectedly"},
+			retriable: true,
+			line:      "error: RPC failed; HTTP 409 Conflict",
+		},
+		{
+			name: "merge conflict",
+			err:  &commandError{stderr: "Auto-merging main.go\nCONFLICT (content): Merge conflict in main.go\nAutomatic merge failed; fix conflicts and then commit the result."},
+			line: "CONFLICT (content): Merge conflict in main.go",
+		},
+		{
+			name: "authentication",
+			err:  &commandError{stderr: "remote: HTTP Basic: Access denied\nfatal: Authentication failed for 'https://gitlab.example.com/grp/api.git/'"},
+			line: "remote: HTTP Basic: Access denied",
+		},
+		{
+			name: "unknown error",
+			err:  &commandError{stderr: "fatal: something unexpected"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retriable, line := retriableGitError(tt.err)
+			if retriable != tt.retriable || line != tt.line {
+				t.Errorf("retriableGitError() = %v, %q, want %v, %q", retriable, line, tt.retriable, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// selectorMatches reports whether an include or exclude selector matches a project path, such as
+// "backend/api/users". Local repositories are matched by their path relative to the directory they
+// are in, which is the project path for repositories cloned by sync.
+//
+// A selector with glob characters (*, ? or [) matches the path or one of its parent directories,
+// so "backend/*" selects every project of the backend group and its subgroups. Any other selector
+// is a path prefix: "backend" and "backend/" select backend and everything under it, and
+// "backend/api/users" selects that one project.
+func selectorMatches(selector, projectPath string) bool {
+	selector = strings.Trim(strings.TrimSpace(selector), "/")
+	projectPath = strings.Trim(filepath.ToSlash(projectPath), "/")
+	if selector == "" {
+		return false
+	}
+	if !strings.ContainsAny(selector, "*?[") {
+		return projectPath == selector || strings.HasPrefix(projectPath, selector+"/")
+	}
+	for prefix := projectPath; prefix != "." && prefix != ""; prefix = path.Dir(prefix) {
+		if matched, err := path.Match(selector, prefix); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorsMatch reports whether any of the selectors matches the project path.
+func selectorsMatch(selectors []string, projectPath string) bool {
+	for _, selector := range selectors {
+		if selectorMatches(selector, projectPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// repoSelected reports whether the project path is selected by the include and exclude selectors:
+// it must match an include selector, if there are any, and no exclude selector.
+func repoSelected(projectPath string, includePatterns, excludePatterns []string) bool {
+	if hasSelectors(includePatterns) && !selectorsMatch(includePatterns, projectPath) {
+		return false
+	}
+	return !selectorsMatch(excludePatterns, projectPath)
+}
+
+// hasSelectors reports whether the list has a selector that is not blank.
+func hasSelectors(selectors []string) bool {
+	for _, selector := range selectors {
+		if strings.TrimSpace(selector) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hermes stashes are named "hermes-<timestamp>-<branch>".
+const (
+	stashPrefix     = "hermes-"
+	stashTimeLayout = "20060102-150405"
+)
+
+// Stash is a stash entry created by Hermes.
+type Stash struct {
+	Repository string
+	Ref        string // e.g. "stash@{0}"; only valid until the stash list changes
+	Name       string
+	Branch     string
+	Created    time.Time
+}
+
+// stashName returns the name of a stash created now for the branch.
+func stashName(branch string) string {
+	return stashPrefix + time.Now().Format(stashTimeLayout) + "-" + branch
+}
+
+// parseStashName extracts the timestamp and branch from a Hermes stash name.
+func parseStashName(name string) (time.Time, string, bool) {
+	rest, ok := strings.CutPrefix(name, stashPrefix)
+	if !ok || len(rest) < len(stashTimeLayout)+2 || rest[len(stashTimeLayout)] != '-' {
+		return time.Time{}, "", false
+	}
+	created, err := time.ParseInLocation(stashTimeLayout, rest[:len(stashTimeLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return created, rest[len(stashTimeLayout)+1:], true
+}
+
+// listStashes returns the Hermes stashes of the repository, newest first.
+func listStashes(repoPath string) ([]Stash, error) {
+	cmd := exec.Command("git", "stash", "list", "--format=%gd%x00%gs")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing stashes: %v", err)
+	}
+
+	var stashes []Stash
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		ref, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		// Stashes created with a message have the subject "On <branch>: <message>".
+		_, name, ok := strings.Cut(subject, ": ")
+		if !ok {
+			continue
+		}
+		created, branch, ok := parseStashName(name)
+		if !ok {
+			continue
+		}
+		stashes = append(stashes, Stash{Repository: repoPath, Ref: ref, Name: name, Branch: branch, Created: created})
+	}
+	return stashes, nil
+}
+
+// findStash returns the current ref of the named stash.
+func findStash(repoPath, name string) (string, error) {
+	stashes, err := listStashes(repoPath)
+	if err != nil {
+		return "", err
+	}
+	for _, stash := range stashes {
+		if stash.Name == name {
+			return stash.Ref, nil
+		}
+	}
+	return "", fmt.Errorf("stash %s not found", name)
+}
+
+// stashChanges stashes uncommitted changes, including untracked files, under a Hermes stash name.
+// It returns an empty name if the repository is clean.
+func stashChanges(logger *logWriter.Logger, repoPath, branch string) (string, error) {
+	dirty, err := isRepoDirty(repoPath)
+	if err != nil || !dirty {
+		return "", err
+	}
+	name := stashName(branch)
+	logger.InfoString("Stashing uncommitted changes on branch %s as %s", branch, name)
+	if err := runCommand(logger, repoPath, "git", "stash", "push", "--include-untracked", "-m", name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// hasConflicts reports whether the index contains unmerged paths.
+func hasConflicts(repoPath string) bool {
+	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(out)) != ""
+}
+
+// applyStash applies the named stash and drops it on success. If the stash does not apply cleanly,
+// conflicting changes are reset and the untracked files it restored are removed, so the working tree
+// matches HEAD again; the stash itself is kept.
+func applyStash(logger *logWriter.Logger, repoPath, name string) error {
+	ref, err := findStash(repoPath, name)
+	if err != nil {
+		return err
+	}
+	logger.InfoString("Applying stash %s (%s)", name, ref)
+	if err := runCommand(logger, repoPath, "git", "stash", "apply", ref); err != nil {
+		if hasConflicts(repoPath) {
+			logger.ErrorString("Conflicts detected while applying stash %s; resetting working tree to HEAD", name)
+			resetRepo(repoPath, logger, "HEAD")
+			removeStashedUntracked(logger, repoPath, ref)
+		}
+		return fmt.Errorf("could not apply stash %s: %v", name, err)
+	}
+	if err := runCommand(logger, repoPath, "git", "stash", "drop", ref); err != nil {
+		logger.ErrorString("Error dropping stash %s: %v", name, err)
+	}
+	return nil
+}
+
+// removeStashedUntracked deletes the untracked files stored in the stash from the working tree.
+// They are still in the stash, so a later restore brings them back.
+func removeStashedUntracked(logger *logWriter.Logger, repoPath, ref string) {
+	cmd := exec.Command("git", "ls-tree", "-r", "--name-only", ref+"^3")
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return // the stash has no untracked files
+	}
+	for _, file := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if file == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(repoPath, file)); err != nil && !os.IsNotExist(err) {
+			logger.ErrorString("Error removing %s: %v", file, err)
+		}
+	}
+}
+
+// restoreStash applies a stash created during the sync. On failure the stash is kept and recorded.
+func (g *GitlabClient) restoreStash(logger *logWriter.Logger, repoKey, repoPath, name string) error {
+	if err := applyStash(logger, repoPath, name); err != nil {
+		logger.ErrorString("%v", err)
+		g.keepStash(logger, repoKey, repoPath, name)
+		return fmt.Errorf("%v; changes kept in stash %s (restore with 'hermes stashes restore %s')", err, name, name)
+	}
+	return nil
+}
+
+// keepStash records a stash that was left in place in the repository result.
+func (g *GitlabClient) keepStash(logger *logWriter.Logger, repoKey, repoPath, name string) {
+	ref, err := findStash(repoPath, name)
+	if err != nil {
+		ref = "?"
+	}
+	logger.YellowString("Uncommitted changes are kept in %s (%s)", ref, name)
+	g.updateResult(repoKey, func(r *RepoResult) { r.KeptStashes = append(r.KeptStashes, ref+" "+name) })
+}
+
+// ListStashes returns the Hermes stashes of every repository under the configured directory
+// that matches the include/exclude patterns.
+func (g *GitlabClient) ListStashes() ([]Stash, error) {
+	repos, err := g.selectedRepositories()
+	if err != nil {
+		return nil, err
+	}
+	var all []Stash
+	for _, repoPath := range repos {
+		stashes, err := listStashes(repoPath)
+		if err != nil {
+			g.logWriter.ErrorString("%s: %v", repoPath, err)
+			continue
+		}
+		all = append(all, stashes...)
+	}
+	return all, nil
+}
+
+// RestoreStash restores the stash in its repository. The working tree must be clean;
+// the branch the stash was created on is checked out first if it exists.
+func (g *GitlabClient) RestoreStash(stash Stash) error {
+	unlock, err := g.lockWorkspace(g.getBaseDir(constant.TargetDir), OperationStashRestore)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	unlockRepo, err := g.lockRepo(g.logWriter, stash.Repository, OperationStashRestore)
+	if err != nil {
+		return err
+	}
+	defer unlockRepo()
+
+	dirty, err := isRepoDirty(stash.Repository)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%s has uncommitted changes; commit or stash them first", stash.Repository)
+	}
+	currentBranch, err := getCurrentBranch(stash.Repository)
+	if err != nil {
+		return err
+	}
+	if currentBranch != stash.Branch && branchExists(stash.Repository, stash.Branch) {
+		if err := runCommand(g.logWriter, stash.Repository, "git", "checkout", stash.Branch); err != nil {
+			return err
+		}
+	}
+	return applyStash(g.logWriter, stash.Repository, stash.Name)
+}
+
+// selectedRepositories returns the repositories under the configured directory
+// that match the include/exclude patterns of the context, or the ones set with SetRepositories.
+func (g *GitlabClient) selectedRepositories() ([]string, error) {
+	if g.repositories != nil {
+		return g.repositories, nil
+	}
+	baseDir := g.getBaseDir(constant.TargetDir)
+	if baseDir == "" {
+		return nil, fmt.Errorf("base directory is empty")
+	}
+	includePatterns, excludePatterns := g.selectors()
+	return findRepositories(baseDir, includePatterns, excludePatterns)
+}
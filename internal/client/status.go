@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RepoStatus is the state of a local repository as shown on the dashboard.
+type RepoStatus struct {
+	Path       string
+	Branch     string // empty when HEAD is detached
+	Upstream   string // empty when the branch has no upstream
+	Dirty      int    // changed, staged and untracked files
+	Ahead      int
+	Behind     int
+	LastCommit time.Time // zero in a repository without commits
+	Stashes    int
+	Remote     string // URL of origin
+	Err        error
+}
+
+// FindRepositories returns the paths of the Git repositories under baseDir.
+func FindRepositories(baseDir string) ([]string, error) {
+	return findRepositories(baseDir, nil, nil)
+}
+
+// ReadRepoStatus reads the status of the repository at path. It only runs local git commands, so
+// ahead and behind are relative to the remote-tracking branch as of the last fetch.
+func ReadRepoStatus(path string) RepoStatus {
+	status := RepoStatus{Path: path}
+	out, err := gitOutput(path, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			if head := strings.TrimPrefix(line, "# branch.head "); head != "(detached)" {
+				status.Branch = head
+			}
+		case strings.HasPrefix(line, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			// "# branch.ab +<ahead> -<behind>"
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			status.Dirty++
+		}
+	}
+
+	if out, err := gitOutput(path, "log", "-1", "--format=%ct"); err == nil && out != "" {
+		if seconds, err := strconv.ParseInt(out, 10, 64); err == nil {
+			status.LastCommit = time.Unix(seconds, 0)
+		}
+	}
+	if out, err := gitOutput(path, "stash", "list"); err == nil && out != "" {
+		status.Stashes = len(strings.Split(out, "\n"))
+	}
+	status.Remote, _ = gitOutput(path, "remote", "get-url", "origin")
+	return status
+}
+
+// LastFetch returns when the remote-tracking refs of the repository were last updated: the time of
+// the last fetch, or of the last update recorded in their reflogs for repositories never fetched
+// since they were cloned. It reports false if neither is known.
+func LastFetch(repoPath string) (time.Time, bool) {
+	if fetchHead, err := gitOutput(repoPath, "rev-parse", "--git-path", "FETCH_HEAD"); err == nil {
+		if info, err := os.Stat(absGitPath(repoPath, fetchHead)); err == nil {
+			return info.ModTime(), true
+		}
+	}
+	logs, err := gitOutput(repoPath, "rev-parse", "--git-path", "logs/refs/remotes")
+	if err != nil {
+		return time.Time{}, false
+	}
+	var latest time.Time
+	filepath.Walk(absGitPath(repoPath, logs), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, !latest.IsZero()
+}
+
+// absGitPath resolves a path printed by "git rev-parse --git-path", which is relative to the repository.
+func absGitPath(repoPath, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(repoPath, path)
+}
+
+// FormatAge formats the time since t in its largest unit, e.g. "5m" or "3d".
+func FormatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(int(d.Seconds()), 0))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/24/365))
+	}
+}
+
+// RefsAge describes how long ago the remote-tracking refs of the repository were updated.
+func RefsAge(repoPath string) string {
+	if updated, ok := LastFetch(repoPath); ok {
+		return "remote refs updated " + FormatAge(updated) + " ago"
+	}
+	return "remote refs never fetched"
+}
+
+// gitOutput runs git in dir and returns its trimmed output; the error includes what git printed.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Clone strategies supported by CloneOrPullRepo.
+const (
+	CloneStrategyFull         = "full"
+	CloneStrategyShallow      = "shallow"
+	CloneStrategyBlobless     = "blobless"
+	CloneStrategySingleBranch = "single-branch"
+	CloneStrategyMirror       = "mirror"
+)
+
+// Sync policies decide how CloneOrPullRepo updates local branches after fetching.
+const (
+	SyncPolicyPull        = "pull"    // check out and pull every branch (default)
+	SyncPolicyFetch       = "fetch"   // only update remote-tracking refs
+	SyncPolicyFastForward = "ff-only" // fast-forward local branches without checking them out
+)
+
+// defaultShallowDepth is used when a shallow strategy is given without a depth.
+const defaultShallowDepth = 1
+
+// CloneStrategy describes how a repository is cloned and kept up to date.
+type CloneStrategy struct {
+	Mode  string
+	Depth int // only used by the shallow strategy
+}
+
+// cloneRule binds a clone strategy to a project selector.
+type cloneRule struct {
+	selector string
+	strategy CloneStrategy
+}
+
+// validSyncPolicy reports whether policy is one of the supported sync policies.
+func validSyncPolicy(policy string) bool {
+	switch policy {
+	case SyncPolicyPull, SyncPolicyFetch, SyncPolicyFastForward:
+		return true
+	}
+	return false
+}
+
+// parseCloneStrategy parses a single strategy such as "full", "blobless" or "shallow:10".
+func parseCloneStrategy(value string) (CloneStrategy, error) {
+	mode, arg, hasArg := strings.Cut(strings.TrimSpace(value), ":")
+	switch mode {
+	case CloneStrategyShallow:
+		depth := defaultShallowDepth
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return CloneStrategy{}, fmt.Errorf("invalid shallow depth %q", arg)
+			}
+			depth = n
+		}
+		return CloneStrategy{Mode: mode, Depth: depth}, nil
+	case CloneStrategyFull, CloneStrategyBlobless, CloneStrategySingleBranch, CloneStrategyMirror:
+		if hasArg {
+			return CloneStrategy{}, fmt.Errorf("clone strategy %q does not take an argument", mode)
+		}
+		return CloneStrategy{Mode: mode}, nil
+	case "":
+		return CloneStrategy{Mode: CloneStrategyFull}, nil
+	default:
+		return CloneStrategy{}, fmt.Errorf("unknown clone strategy %q", mode)
+	}
+}
+
+// parseCloneRules parses a comma-separated list of "selector=strategy" pairs,
+// e.g. "backend/monorepo=shallow:1,backup/*=mirror,*=blobless".
+func parseCloneRules(spec string) ([]cloneRule, error) {
+	var rules []cloneRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		selector, value, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(selector) == "" {
+			return nil, fmt.Errorf("invalid clone strategy rule %q (expected selector=strategy)", part)
+		}
+		strategy, err := parseCloneStrategy(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clone strategy rule %q: %v", part, err)
+		}
+		rules = append(rules, cloneRule{selector: strings.TrimSpace(selector), strategy: strategy})
+	}
+	return rules, nil
+}
+
+// matches reports whether the selector matches the project path, either as a glob or as a substring.
+// A lone "*" matches every project, including nested groups.
+func (r cloneRule) matches(projectPath string) bool {
+	if r.selector == "*" {
+		return true
+	}
+	if matched, err := path.Match(r.selector, projectPath); err == nil && matched {
+		return true
+	}
+	return strings.Contains(projectPath, r.selector)
+}
+
+// cloneStrategyFor returns the strategy of the first rule matching the project path, or a full clone.
+func (g *GitlabClient) cloneStrategyFor(projectPath string) CloneStrategy {
+	for _, rule := range g.cloneRules {
+		if rule.matches(projectPath) {
+			return rule.strategy
+		}
+	}
+	return CloneStrategy{Mode: CloneStrategyFull}
+}
+
+// cloneArgs returns the "git clone" arguments for the strategy.
+// branch restricts single-branch clones to that branch when it is not empty.
+func (s CloneStrategy) cloneArgs(repoURL, repoPath, branch string) []string {
+	args := []string{"clone"}
+	switch s.Mode {
+	case CloneStrategyShallow:
+		args = append(args, "--depth", strconv.Itoa(s.Depth))
+	case CloneStrategyBlobless:
+		args = append(args, "--filter=blob:none")
+	case CloneStrategySingleBranch:
+		args = append(args, "--single-branch")
+		if branch != "" {
+			args = append(args, "--branch", branch)
+		}
+	case CloneStrategyMirror:
+		args = append(args, "--mirror")
+	}
+	return append(args, repoURL, repoPath)
+}
+
+// String returns the strategy in the same form it is configured with.
+func (s CloneStrategy) String() string {
+	if s.Mode == CloneStrategyShallow {
+		return fmt.Sprintf("%s:%d", s.Mode, s.Depth)
+	}
+	return s.Mode
+}
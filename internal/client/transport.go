@@ -0,0 +1,108 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/config"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// newHTTPTransport returns the transport of the GitLab API client with the configured CA bundle,
+// client certificate, proxy and certificate verification.
+func newHTTPTransport(cfg *config.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLSInsecureSkipVerify}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading TLS_CA_FILE: %v", err)
+		}
+		// The bundle is added to the system roots, so public certificates keep working.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS_CA_FILE %s contains no PEM certificates", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// Without PROXY_URL the standard proxy environment variables still apply.
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid PROXY_URL %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
+
+// gitTransportEnv adds to env the environment that applies the same CA bundle, client certificate,
+// proxy and certificate verification to git subprocesses. The CA bundle, client certificate and
+// disabled verification are set for the GitLab host only, so other HTTPS hosts, such as those of
+// submodules, keep the system roots and are always verified: git, unlike the API client, replaces
+// the system roots with the bundle it is given.
+func gitTransportEnv(env []string, cfg *config.Config) []string {
+	var gitConfig []string
+	scope := "http."
+	if u, err := url.Parse(cfg.GitlabBaseURL); err == nil && u.Scheme != "" && u.Host != "" {
+		scope = fmt.Sprintf("http.%s://%s/.", u.Scheme, u.Host)
+	}
+	if cfg.TLSCAFile != "" {
+		gitConfig = append(gitConfig, scope+"sslCAInfo", cfg.TLSCAFile)
+	}
+	if cfg.TLSCertFile != "" {
+		gitConfig = append(gitConfig, scope+"sslCert", cfg.TLSCertFile, scope+"sslKey", cfg.TLSKeyFile)
+	}
+	if cfg.TLSInsecureSkipVerify {
+		gitConfig = append(gitConfig, scope+"sslVerify", "false")
+	}
+	if cfg.ProxyURL != "" {
+		env = append(env, "http_proxy="+cfg.ProxyURL, "https_proxy="+cfg.ProxyURL, "HTTPS_PROXY="+cfg.ProxyURL)
+	}
+	return addGitConfig(env, gitConfig...)
+}
+
+// addGitConfig adds configuration entries, given as key and value pairs, to the GIT_CONFIG_*
+// variables of env, after the entries it already has.
+func addGitConfig(env []string, keyValues ...string) []string {
+	if len(keyValues) == 0 {
+		return env
+	}
+	count, index := 0, -1
+	for i, v := range env {
+		if n, ok := strings.CutPrefix(v, "GIT_CONFIG_COUNT="); ok {
+			count, _ = strconv.Atoi(n)
+			index = i
+		}
+	}
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, keyValues[i]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, keyValues[i+1]))
+		count++
+	}
+	if index >= 0 {
+		env = slices.Delete(env, index, index+1)
+	}
+	return append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", count))
+}
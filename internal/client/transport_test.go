@@ -0,0 +1,63 @@
+package client
+
+import (
+	"github.com/sinaw369/Hermes/internal/config"
+	"reflect"
+	"testing"
+)
+
+func TestGitTransportEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		cfg  config.Config
+		want []string
+	}{
+		{
+			name: "nothing configured",
+			cfg:  config.Config{GitlabBaseURL: "https://gitlab.example.com/api/v4"},
+		},
+		{
+			name: "scoped to the GitLab host",
+			cfg: config.Config{
+				GitlabBaseURL:         "https://gitlab.example.com/api/v4",
+				TLSCAFile:             "/ca.pem",
+				TLSInsecureSkipVerify: true,
+			},
+			want: []string{
+				"GIT_CONFIG_KEY_0=http.https://gitlab.example.com/.sslCAInfo", "GIT_CONFIG_VALUE_0=/ca.pem",
+				"GIT_CONFIG_KEY_1=http.https://gitlab.example.com/.sslVerify", "GIT_CONFIG_VALUE_1=false",
+				"GIT_CONFIG_COUNT=2",
+			},
+		},
+		{
+			name: "after the credential helper",
+			env:  []string{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=credential.helper", "GIT_CONFIG_VALUE_0="},
+			cfg: config.Config{
+				GitlabBaseURL: "https://gitlab.example.com",
+				TLSCertFile:   "/cert.pem",
+				TLSKeyFile:    "/key.pem",
+				ProxyURL:      "http://proxy:3128",
+			},
+			want: []string{
+				"GIT_CONFIG_KEY_0=credential.helper", "GIT_CONFIG_VALUE_0=",
+				"http_proxy=http://proxy:3128", "https_proxy=http://proxy:3128", "HTTPS_PROXY=http://proxy:3128",
+				"GIT_CONFIG_KEY_1=http.https://gitlab.example.com/.sslCert", "GIT_CONFIG_VALUE_1=/cert.pem",
+				"GIT_CONFIG_KEY_2=http.https://gitlab.example.com/.sslKey", "GIT_CONFIG_VALUE_2=/key.pem",
+				"GIT_CONFIG_COUNT=3",
+			},
+		},
+		{
+			name: "without GitLab URL",
+			cfg:  config.Config{TLSInsecureSkipVerify: true},
+			want: []string{"GIT_CONFIG_KEY_0=http.sslVerify", "GIT_CONFIG_VALUE_0=false", "GIT_CONFIG_COUNT=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gitTransportEnv(tt.env, &tt.cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gitTransportEnv() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
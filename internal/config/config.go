@@ -1,9 +1,62 @@
 package config
 
+import "time"
+
 type Config struct {
-	GitlabBaseURL  string
+	// Profile is the name of the profile of the config file in use, if any; File is the config file path.
+	Profile string
+	File    string
+
+	GitlabBaseURL string
+	// GitlabToken is a plain GITLAB_TOKEN until Require(KeyGitlabToken) resolves it from its configured source.
 	GitlabToken    string
 	WorkingDir     string
 	DiffBranchFrom string
 	DifBranchTO    string
+	// DiffFetch makes diffs fetch the two compared branches from origin first.
+	DiffFetch bool
+	// Include and Exclude are the default comma-separated selectors of commands run without --include/--exclude.
+	Include string
+	Exclude string
+	// Concurrency is the number of repositories processed at once; zero keeps each command's default.
+	Concurrency   int
+	CloneProtocol string
+	// CloneStrategies is a comma-separated list of "selector=strategy" rules.
+	CloneStrategies string
+	// SyncPolicy is one of "pull", "fetch" or "ff-only".
+	SyncPolicy string
+	// StateDir holds run summaries and other state written by Hermes.
+	StateDir string
+	// LockWait is how long to wait for a workspace or repository lock; zero fails right away.
+	LockWait time.Duration
+	// LockRepositories enables per-repository locks in addition to the workspace lock.
+	LockRepositories bool
+	// APITimeout bounds every GitLab API request, including reading the response.
+	APITimeout time.Duration
+	// APIRetries is how often a failed GitLab API request is retried.
+	APIRetries int
+	// APIRateLimit caps GitLab API requests per second; zero leaves it to the instance's RateLimit headers.
+	APIRateLimit float64
+	// GitRetryAttempts is how often a git network command is attempted; 1 disables retries.
+	GitRetryAttempts int
+	// GitRetryBackoff is the wait before the first retry; it doubles with every further attempt.
+	GitRetryBackoff time.Duration
+	// TLSCAFile is a PEM bundle trusted in addition to the system roots.
+	TLSCAFile string
+	// TLSCertFile and TLSKeyFile are a client certificate presented to GitLab.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSInsecureSkipVerify disables certificate verification; only for testing.
+	TLSInsecureSkipVerify bool
+	// ProxyURL is the HTTP proxy for the API and for git over HTTPS.
+	ProxyURL string
+
+	// FormHistory is how many submissions of each TUI form are kept in the state directory.
+	FormHistory int
+
+	// tokenSource resolves GitlabToken when a command requires it.
+	tokenSource tokenSource
+	// values and sources are the raw value and the source of every key, for the settings screen.
+	values  map[string]string
+	sources map[string]string
 }
@@ -2,63 +2,86 @@ package config
 
 import (
 	"fmt"
+	"github.com/spf13/cast"
 	"github.com/spf13/viper"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-func loadString(envName string) string {
-	validate(envName)
-	value := viper.GetString(envName)
+// loader reads typed values from viper and collects conversion errors, so Load can report
+// every invalid key at once instead of stopping at the first one.
+type loader struct {
+	errs []string
+}
 
-	if value == "" {
-		panic(fmt.Sprintf("Warning: environment variable [%s] is empty", envName))
-	}
-	return value
+func (l *loader) fail(envName string, err error) {
+	l.errs = append(l.errs, fmt.Sprintf("%s: %v", envName, err))
 }
-func loadFilePath(envName string) string {
-	validate(envName)
-	path := viper.GetString(envName)
 
-	if !filepath.IsAbs(path) {
-		panic(fmt.Sprintf("[%s] should be full path : %s", envName, path))
-	}
-	if path == "" {
-		panic(fmt.Sprintf("Warning: environment variable [%s] is empty", envName))
+// err returns the collected errors, or nil.
+func (l *loader) err() error {
+	if len(l.errs) == 0 {
+		return nil
 	}
-	return path
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(l.errs, "\n  "))
 }
-func loadInt(envName string) int {
-	validate(envName)
-	return viper.GetInt(envName)
-}
-func LoadDuration(envName string) time.Duration {
-	validate(envName)
 
-	return viper.GetDuration(envName)
+// loadString returns the value of envName, or an empty string if it is not set.
+func (l *loader) loadString(envName string) string {
+	return viper.GetString(envName)
 }
 
-func loadBool(envName string) bool {
-	validate(envName)
-
-	return viper.GetBool(envName)
+// loadFilePath returns the path in envName, which must be absolute if it is set.
+func (l *loader) loadFilePath(envName string) string {
+	path := viper.GetString(envName)
+	if path != "" && !filepath.IsAbs(path) {
+		l.fail(envName, fmt.Errorf("should be full path: %s", path))
+	}
+	return path
 }
-func loadFloat64(envName string) float64 {
-	validate(envName)
 
-	return viper.GetFloat64(envName)
+// loadList returns a comma-separated list, which may also be given as a YAML sequence.
+func (l *loader) loadList(envName string) string {
+	value := viper.Get(envName)
+	if items, ok := value.([]interface{}); ok {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, cast.ToString(item))
+		}
+		return strings.Join(values, ",")
+	}
+	return cast.ToString(value)
+}
 
+func (l *loader) loadInt(envName string) int {
+	value, err := cast.ToIntE(viper.Get(envName))
+	if err != nil {
+		l.fail(envName, err)
+	}
+	return value
 }
 
-func loadStringSlice(envName string) []string {
-	validate(envName)
+func (l *loader) loadDuration(envName string) time.Duration {
+	value, err := cast.ToDurationE(viper.Get(envName))
+	if err != nil {
+		l.fail(envName, err)
+	}
+	return value
+}
 
-	return viper.GetStringSlice(envName)
+func (l *loader) loadBool(envName string) bool {
+	value, err := cast.ToBoolE(viper.Get(envName))
+	if err != nil {
+		l.fail(envName, err)
+	}
+	return value
 }
 
-func validate(envName string) {
-	exists := viper.IsSet(envName)
-	if !exists {
-		panic(fmt.Sprintf("environment variable [%s] does not exist", envName))
+func (l *loader) loadFloat64(envName string) float64 {
+	value, err := cast.ToFloat64E(viper.Get(envName))
+	if err != nil {
+		l.fail(envName, err)
 	}
+	return value
 }
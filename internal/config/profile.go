@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// configFile is the layout of config.yaml. Profile keys are the environment variable names in
+// lower case, e.g. gitlab_base_url or working_dir.
+type configFile struct {
+	DefaultProfile string                            `yaml:"default_profile"`
+	Profiles       map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+// profileKeys are the keys a profile may set.
+var profileKeys = []string{
+	KeyGitlabBaseURL, KeyGitlabToken, KeyGitlabTokenEnv, KeyGitlabTokenFile, KeyGitlabTokenCommand, KeyGitlabTokenCredential, KeyWorkingDir, KeyDiffBranchFrom, KeyDiffBranchTo, "DIFF_FETCH",
+	KeyInclude, KeyExclude, KeyConcurrency,
+	KeyCloneProtocol, "CLONE_STRATEGIES", "SYNC_POLICY", "STATE_DIR", "FORM_HISTORY", "LOCK_WAIT", "LOCK_REPOSITORIES",
+	"API_TIMEOUT", "API_RETRIES", "API_RATE_LIMIT", "GIT_RETRY_ATTEMPTS", "GIT_RETRY_BACKOFF",
+	"TLS_CA_FILE", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_INSECURE_SKIP_VERIFY", "PROXY_URL",
+}
+
+// FilePath returns the path of the YAML config file: $HERMES_CONFIG if set, otherwise
+// $XDG_CONFIG_HOME/hermes/config.yaml or ~/.config/hermes/config.yaml.
+func FilePath() string {
+	if path := os.Getenv("HERMES_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "hermes", "config.yaml")
+}
+
+// loadProfile reads the named profile from the config file at path. Without a name, the file's
+// default profile is used. A missing file is only an error if a profile was asked for.
+func loadProfile(path, name string) (map[string]interface{}, string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && name == "" {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("reading config file: %w", err)
+	}
+
+	var file configFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if name == "" {
+		name = file.DefaultProfile
+	}
+	if name == "" {
+		switch len(file.Profiles) {
+		case 0:
+			return nil, "", nil
+		case 1:
+			for only := range file.Profiles {
+				name = only
+			}
+		default:
+			return nil, "", fmt.Errorf("%s has several profiles but no default_profile; choose one with --profile (%s)", path, strings.Join(profileNames(file), ", "))
+		}
+	}
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return nil, "", fmt.Errorf("profile %q not found in %s (available: %s)", name, path, strings.Join(profileNames(file), ", "))
+	}
+
+	known := make(map[string]bool, len(profileKeys))
+	for _, key := range profileKeys {
+		known[strings.ToLower(key)] = true
+	}
+	for key := range profile {
+		if !known[strings.ToLower(key)] {
+			return nil, "", fmt.Errorf("profile %q in %s: unknown key %q", name, path, key)
+		}
+	}
+	return profile, name, nil
+}
+
+// profileNames returns the sorted profile names of the file.
+func profileNames(file configFile) []string {
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveProfile writes the values to the named profile of the config file at path, creating the file if
+// needed. Keys the profile already has are kept unless values sets them. The first profile written
+// becomes the default profile. Comments in an existing file are not preserved.
+func SaveProfile(path, name string, values map[string]interface{}) error {
+	var file configFile
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]map[string]interface{})
+	}
+	profile := file.Profiles[name]
+	if profile == nil {
+		profile = make(map[string]interface{})
+	}
+	for key, value := range values {
+		profile[strings.ToLower(key)] = value
+	}
+	file.Profiles[name] = profile
+	if file.DefaultProfile == "" {
+		file.DefaultProfile = name
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&file); err != nil {
+		return err
+	}
+	return writePrivateFile(path, buf.Bytes())
+}
+
+// writePrivateFile atomically replaces the file at path with data, readable by its owner only.
+func writePrivateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -4,26 +4,125 @@ import (
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
 )
 
-func Load() (config *Config, err error) {
+// Load reads the configuration. Values are taken, from lowest to highest priority, from the defaults,
+// .env in the current directory, the selected profile of the YAML config file and the environment.
+// profile selects a profile of the config file; if it is empty, the file's default profile is used.
+// Load does not require any key to be set; commands check the keys they need with Require.
+func Load(profile string) (config *Config, err error) {
 
+	// Load may run again to switch profiles, so no value of an earlier profile may remain.
+	viper.Reset()
 	viper.SetConfigType("env")
 	viper.AddConfigPath(".")
 	viper.SetConfigName(".env")
 	viper.AutomaticEnv()
+	viper.SetDefault("CLONE_PROTOCOL", "ssh")
+	viper.SetDefault("SYNC_POLICY", "pull")
+	viper.SetDefault("STATE_DIR", defaultStateDir())
+	viper.SetDefault("CONCURRENCY", 0)
+	viper.SetDefault("FORM_HISTORY", 20)
+	viper.SetDefault("LOCK_WAIT", "0s")
+	viper.SetDefault("LOCK_REPOSITORIES", false)
+	viper.SetDefault("DIFF_FETCH", false)
+	viper.SetDefault("API_TIMEOUT", "30s")
+	viper.SetDefault("API_RETRIES", 5)
+	viper.SetDefault("API_RATE_LIMIT", 0)
+	viper.SetDefault("GIT_RETRY_ATTEMPTS", 3)
+	viper.SetDefault("GIT_RETRY_BACKOFF", "2s")
+	viper.SetDefault("TLS_INSECURE_SKIP_VERIFY", false)
+	viper.SetDefault(KeyGitlabTokenCredential, false)
 	if err := viper.ReadInConfig(); err != nil {
 		if !errors.As(err, &viper.ConfigFileNotFoundError{}) {
 			return nil, fmt.Errorf("reading config: %w", err)
 		}
 	}
 
-	return &Config{
-		GitlabBaseURL:  loadString("GITLAB_BASE_URL"),
-		GitlabToken:    loadString("GITLAB_TOKEN"),
-		WorkingDir:     loadFilePath("WORKING_DIR"),
-		DiffBranchFrom: loadString("DIFF_BRANCH_FROM"),
-		DifBranchTO:    loadString("DIFF_BRANCH_TO"),
-	}, nil
+	if profile == "" {
+		profile = os.Getenv("HERMES_PROFILE")
+	}
+	// Keys set in .env are recorded before the profile is merged into the same layer.
+	dotEnv := make(map[string]bool, len(profileKeys))
+	for _, key := range profileKeys {
+		dotEnv[key] = viper.InConfig(key)
+	}
+
+	file := FilePath()
+	values, profile, err := loadProfile(file, profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := viper.MergeConfigMap(values); err != nil {
+		return nil, fmt.Errorf("applying profile %q: %w", profile, err)
+	}
+
+	l := &loader{}
+	config = &Config{
+		Profile:               profile,
+		File:                  file,
+		GitlabBaseURL:         l.loadString("GITLAB_BASE_URL"),
+		GitlabToken:           l.loadString("GITLAB_TOKEN"),
+		WorkingDir:            l.loadFilePath("WORKING_DIR"),
+		DiffBranchFrom:        l.loadString("DIFF_BRANCH_FROM"),
+		DifBranchTO:           l.loadString("DIFF_BRANCH_TO"),
+		DiffFetch:             l.loadBool("DIFF_FETCH"),
+		Include:               l.loadList("INCLUDE"),
+		Exclude:               l.loadList("EXCLUDE"),
+		Concurrency:           l.loadInt("CONCURRENCY"),
+		CloneProtocol:         l.loadString("CLONE_PROTOCOL"),
+		CloneStrategies:       l.loadList("CLONE_STRATEGIES"),
+		SyncPolicy:            l.loadString("SYNC_POLICY"),
+		StateDir:              l.loadFilePath("STATE_DIR"),
+		FormHistory:           l.loadInt("FORM_HISTORY"),
+		LockWait:              l.loadDuration("LOCK_WAIT"),
+		LockRepositories:      l.loadBool("LOCK_REPOSITORIES"),
+		APITimeout:            l.loadDuration("API_TIMEOUT"),
+		APIRetries:            l.loadInt("API_RETRIES"),
+		APIRateLimit:          l.loadFloat64("API_RATE_LIMIT"),
+		GitRetryAttempts:      l.loadInt("GIT_RETRY_ATTEMPTS"),
+		GitRetryBackoff:       l.loadDuration("GIT_RETRY_BACKOFF"),
+		TLSCAFile:             l.loadFilePath("TLS_CA_FILE"),
+		TLSCertFile:           l.loadFilePath("TLS_CERT_FILE"),
+		TLSKeyFile:            l.loadFilePath("TLS_KEY_FILE"),
+		TLSInsecureSkipVerify: l.loadBool("TLS_INSECURE_SKIP_VERIFY"),
+		ProxyURL:              l.loadString("PROXY_URL"),
+	}
+	// The token is resolved from its source once a command requires it.
+	config.tokenSource = tokenSource{
+		command:    l.loadString(KeyGitlabTokenCommand),
+		file:       l.loadFilePath(KeyGitlabTokenFile),
+		env:        l.loadString(KeyGitlabTokenEnv),
+		credential: l.loadBool(KeyGitlabTokenCredential),
+	}
+	raw := make(map[string]string, len(profileKeys))
+	for _, key := range profileKeys {
+		raw[key] = l.loadList(key)
+	}
+	config.recordSources(raw, func(key string) bool { return dotEnv[key] }, values)
+	if config.Concurrency < 0 {
+		l.fail("CONCURRENCY", fmt.Errorf("must not be negative"))
+	}
+	if config.FormHistory < 0 {
+		l.fail("FORM_HISTORY", fmt.Errorf("must not be negative"))
+	}
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+	return config, nil
 
 }
+
+// defaultStateDir returns $XDG_STATE_HOME/hermes, falling back to ~/.local/state/hermes.
+func defaultStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "hermes")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "hermes")
+	}
+	return filepath.Join(home, ".local", "state", "hermes")
+}
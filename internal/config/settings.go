@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sources of configuration values, from lowest to highest priority. Values from a profile have the
+// source `profile "<name>"`.
+const (
+	SourceDefault     = "default"
+	SourceDotEnv      = ".env"
+	SourceEnvironment = "environment"
+	SourceUI          = "changed in the UI"
+)
+
+// Keys that can be changed while Hermes runs.
+const (
+	KeyConcurrency   = "CONCURRENCY"
+	KeyCloneProtocol = "CLONE_PROTOCOL"
+	KeyInclude       = "INCLUDE"
+	KeyExclude       = "EXCLUDE"
+)
+
+// EditableKeys are the keys the settings screen can change.
+var EditableKeys = []string{
+	KeyWorkingDir, KeyDiffBranchFrom, KeyDiffBranchTo, KeyConcurrency, KeyCloneProtocol, KeyInclude, KeyExclude,
+}
+
+// secretKeys are never shown.
+var secretKeys = map[string]bool{KeyGitlabToken: true}
+
+// Setting is the effective value of a key and where it came from.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// profileSource is the source of values from the named profile.
+func profileSource(name string) string {
+	return fmt.Sprintf("profile %q", name)
+}
+
+// recordSources remembers the raw value and the source of every key. dotEnv tells whether .env set
+// a key; profile holds the values of the selected profile.
+func (c *Config) recordSources(values map[string]string, dotEnv func(string) bool, profile map[string]interface{}) {
+	inProfile := make(map[string]bool, len(profile))
+	for key := range profile {
+		inProfile[strings.ToUpper(key)] = true
+	}
+	c.values = values
+	c.sources = make(map[string]string, len(values))
+	for key := range values {
+		switch {
+		case os.Getenv(key) != "":
+			c.sources[key] = SourceEnvironment
+		case inProfile[key]:
+			c.sources[key] = profileSource(c.Profile)
+		case dotEnv(key):
+			c.sources[key] = SourceDotEnv
+		default:
+			c.sources[key] = SourceDefault
+		}
+	}
+}
+
+// Source returns where the value of key came from.
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Settings returns the effective value and source of every key, with secrets masked.
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, 0, len(profileKeys))
+	for _, key := range profileKeys {
+		value := c.values[key]
+		if secretKeys[key] && value != "" {
+			value = "********"
+		}
+		if key == KeyGitlabToken && c.TokenSource() != KeyGitlabToken {
+			value = "read from " + c.TokenSource()
+		}
+		settings = append(settings, Setting{Key: key, Value: value, Source: c.Source(key)})
+	}
+	return settings
+}
+
+// ValidateSetting checks a new value of an editable key with the rules of Load. The current value
+// is always accepted, so a setting that was not edited never blocks applying the others.
+func (c *Config) ValidateSetting(key, value string) error {
+	value = strings.TrimSpace(value)
+	if value == c.values[key] {
+		return nil
+	}
+	switch key {
+	case KeyWorkingDir:
+		if value != "" && !filepath.IsAbs(value) {
+			return fmt.Errorf("should be full path: %s", value)
+		}
+	case KeyConcurrency:
+		n, err := strconv.Atoi(value)
+		if value != "" && (err != nil || n < 0) {
+			return fmt.Errorf("must be a number of at least 0 (0 keeps each command's default)")
+		}
+	case KeyCloneProtocol:
+		if value != "ssh" && value != "https" {
+			return fmt.Errorf("must be ssh or https")
+		}
+	}
+	return nil
+}
+
+// Apply validates and sets new values of editable keys for the rest of the run, and returns the keys
+// whose value changed. Nothing is set if a value is invalid.
+func (c *Config) Apply(values map[string]string) ([]string, error) {
+	for key, value := range values {
+		if err := c.ValidateSetting(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	var changed []string
+	for _, key := range EditableKeys {
+		value, ok := values[key]
+		value = strings.TrimSpace(value)
+		if !ok || value == c.values[key] {
+			continue
+		}
+		switch key {
+		case KeyWorkingDir:
+			c.WorkingDir = value
+		case KeyDiffBranchFrom:
+			c.DiffBranchFrom = value
+		case KeyDiffBranchTo:
+			c.DifBranchTO = value
+		case KeyConcurrency:
+			c.Concurrency, _ = strconv.Atoi(value)
+		case KeyCloneProtocol:
+			c.CloneProtocol = value
+		case KeyInclude:
+			c.Include = value
+		case KeyExclude:
+			c.Exclude = value
+		}
+		if c.values == nil {
+			c.values = make(map[string]string)
+			c.sources = make(map[string]string)
+		}
+		c.values[key] = value
+		c.sources[key] = SourceUI
+		changed = append(changed, key)
+	}
+	return changed, nil
+}
+
+// SaveSettings writes the keys to the active profile of the config file, or to the profile named
+// "default" if none is active, and returns the profile name. Without a config file path the
+// default one (see FilePath) is used.
+func (c *Config) SaveSettings(keys []string) (string, error) {
+	if c.File == "" {
+		c.File = FilePath()
+	}
+	if c.File == "" {
+		return "", fmt.Errorf("no config file: set HERMES_CONFIG to the file to save the settings to, or run 'hermes init'")
+	}
+	name := c.Profile
+	if name == "" {
+		name = "default"
+	}
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		values[key] = c.values[key]
+	}
+	if _, ok := values[KeyConcurrency]; ok {
+		values[KeyConcurrency] = c.Concurrency
+	}
+	if err := SaveProfile(c.File, name, values); err != nil {
+		return "", err
+	}
+	c.Profile = name
+	for _, key := range keys {
+		// The environment still wins over the profile once Hermes restarts.
+		if os.Getenv(key) == "" {
+			c.sources[key] = profileSource(name)
+		}
+	}
+	return name, nil
+}
+
+// ProfileNames returns the names of the profiles in the config file at path.
+func ProfileNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file configFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return profileNames(file), nil
+}
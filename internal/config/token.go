@@ -0,0 +1,173 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/redact"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Keys of the token sources. They are tried in this order, before a plain GITLAB_TOKEN.
+const (
+	KeyGitlabTokenCommand    = "GITLAB_TOKEN_COMMAND"
+	KeyGitlabTokenFile       = "GITLAB_TOKEN_FILE"
+	KeyGitlabTokenCredential = "GITLAB_TOKEN_CREDENTIAL"
+)
+
+// tokenCommandTimeout bounds GITLAB_TOKEN_COMMAND and the git credential helper.
+const tokenCommandTimeout = 30 * time.Second
+
+// tokenSource describes where the GitLab token comes from. The token is only resolved when a
+// command needs it, and is kept in memory only.
+type tokenSource struct {
+	command    string
+	file       string
+	env        string
+	credential bool
+	resolved   bool
+	err        error
+}
+
+// resolveToken fills GitlabToken from the configured token source, once, and registers the
+// token for redaction from log output.
+func (c *Config) resolveToken() error {
+	src := &c.tokenSource
+	if src.resolved {
+		return src.err
+	}
+	src.resolved = true
+
+	var token string
+	var err error
+	switch {
+	case src.command != "":
+		token, err = tokenFromCommand(src.command)
+	case src.file != "":
+		token, err = tokenFromFile(src.file)
+	case src.env != "":
+		if token = os.Getenv(src.env); token == "" {
+			err = fmt.Errorf("%s: environment variable %s is empty", KeyGitlabTokenEnv, src.env)
+		}
+	case src.credential:
+		token, err = tokenFromCredentialHelper(c.GitlabBaseURL)
+	default:
+		token = c.GitlabToken
+	}
+	if err != nil {
+		src.err = err
+		return err
+	}
+	c.GitlabToken = strings.TrimSpace(token)
+	redact.Add(c.GitlabToken)
+	return nil
+}
+
+// tokenFromCommand runs the command through the shell and returns the first line of its output,
+// e.g. for "pass show gitlab/token" or "op read op://vault/gitlab/token". The command's stdin is the
+// null device: the TUI owns the terminal, so the command must not prompt; unlock the password manager
+// beforehand or use its agent.
+func tokenFromCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v", KeyGitlabTokenCommand, commandFailure(err, stderr))
+	}
+	token, _, _ := strings.Cut(string(out), "\n")
+	if strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%s printed no token", KeyGitlabTokenCommand)
+	}
+	return token, nil
+}
+
+// tokenFromFile reads the token from a file that only its owner may read.
+func tokenFromFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", KeyGitlabTokenFile, err)
+	}
+	// Windows has no Unix permission bits to check.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("%s: %s is accessible by other users (mode %04o); run 'chmod 600 %s'",
+			KeyGitlabTokenFile, path, info.Mode().Perm(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", KeyGitlabTokenFile, err)
+	}
+	token, _, _ := strings.Cut(string(data), "\n")
+	if strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%s: %s is empty", KeyGitlabTokenFile, path)
+	}
+	return token, nil
+}
+
+// SaveTokenFile writes the token to a file that only its owner may read, for use as GITLAB_TOKEN_FILE.
+func SaveTokenFile(path, token string) error {
+	return writePrivateFile(path, []byte(strings.TrimSpace(token)+"\n"))
+}
+
+// tokenFromCredentialHelper asks git's configured credential helpers for the password of the
+// GitLab host, using the "git credential fill" protocol.
+func tokenFromCredentialHelper(baseURL string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%s: GITLAB_BASE_URL %q is not a valid URL", KeyGitlabTokenCredential, baseURL)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", u.Scheme, u.Host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: git credential fill failed: %v", KeyGitlabTokenCredential, commandFailure(err, stderr))
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if password, ok := strings.CutPrefix(scanner.Text(), "password="); ok && password != "" {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no credential stored for %s", KeyGitlabTokenCredential, u.Host)
+}
+
+// commandFailure adds the command's stderr, if any, to its error.
+func commandFailure(err error, stderr bytes.Buffer) string {
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return fmt.Sprintf("%v: %s", err, msg)
+	}
+	return err.Error()
+}
+
+// TokenSource names the key the GitLab token is read from.
+func (c *Config) TokenSource() string {
+	switch src := c.tokenSource; {
+	case src.command != "":
+		return KeyGitlabTokenCommand
+	case src.file != "":
+		return KeyGitlabTokenFile
+	case src.env != "":
+		return KeyGitlabTokenEnv + " (" + src.env + ")"
+	case src.credential:
+		return KeyGitlabTokenCredential
+	}
+	return KeyGitlabToken
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keys that commands may require. Profiles use the same names in lower case.
+const (
+	KeyGitlabBaseURL  = "GITLAB_BASE_URL"
+	KeyGitlabToken    = "GITLAB_TOKEN"
+	KeyGitlabTokenEnv = "GITLAB_TOKEN_ENV"
+	KeyWorkingDir     = "WORKING_DIR"
+	KeyDiffBranchFrom = "DIFF_BRANCH_FROM"
+	KeyDiffBranchTo   = "DIFF_BRANCH_TO"
+)
+
+// Require returns an error listing the keys that are not set. Commands call it with the keys
+// they need, so a missing key only matters for the commands that use it.
+func (c *Config) Require(keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if key == KeyGitlabToken {
+			if err := c.resolveToken(); err != nil {
+				return err
+			}
+		}
+		if c.value(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if len(missing) == 1 {
+		return fmt.Errorf("%s is not set; set it %s", missing[0], c.sourceHint())
+	}
+	return fmt.Errorf("%s are not set; set them %s", strings.Join(missing, ", "), c.sourceHint())
+}
+
+// value returns the value of a requirable key.
+func (c *Config) value(key string) string {
+	switch key {
+	case KeyGitlabBaseURL:
+		return c.GitlabBaseURL
+	case KeyGitlabToken:
+		return c.GitlabToken
+	case KeyWorkingDir:
+		return c.WorkingDir
+	case KeyDiffBranchFrom:
+		return c.DiffBranchFrom
+	case KeyDiffBranchTo:
+		return c.DifBranchTO
+	}
+	return ""
+}
+
+// sourceHint tells where the missing keys can be set.
+func (c *Config) sourceHint() string {
+	if c.Profile != "" {
+		return fmt.Sprintf("in profile %q of %s, in .env or in the environment", c.Profile, c.File)
+	}
+	return fmt.Sprintf("in .env, in the environment or in a profile of %s", FilePath())
+}
@@ -3,12 +3,18 @@ package constant
 const (
 	LApplication = "Application"
 	LGitClient   = "Git Client"
+	LDiffReport  = "Diff Report"
 
 	OptionListPullPr                   = "Pull PR"
 	OptionListAutoMergeReq             = "Auto Merge Request"
 	OptionListShowProject              = "Files"
+	OptionListDashboard                = "Dashboard"
 	OptionListLogs                     = "Logs"
+	OptionListSettings                 = "Settings"
 	PullFieldPath                      = "Dir Path"
+	PullFieldSyncPolicy                = "Sync Policy"
+	PullFieldOneBranch                 = "One Branch Only"
+	PullFieldBranch                    = "Pull Branch"
 	MergeFieldCommand                  = "merge Command"
 	MergeFieldBranch                   = "Branch Name"
 	MergeFieldCommitMessage            = "Commit Message"
@@ -26,6 +32,14 @@ const (
 	ContextValueDir          = "Dir Path"
 	TargetDir                = "dir"
 	SilentMode               = "silent mode"
+
+	ContextValueCloneProtocol = "CLONE_PROTOCOL"
+	CloneProtocolSSH          = "ssh"
+	CloneProtocolHTTPS        = "https"
+	ContextValueCloneStrategy = "CLONE_STRATEGY"
+	ContextValueSyncPolicy    = "SYNC_POLICY"
+	ContextValuePrune         = "PRUNE"
+	ContextValueLockWait      = "LOCK_WAIT"
 )
 
 const AppLogo = `
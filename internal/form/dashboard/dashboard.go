@@ -0,0 +1,502 @@
+// File: forms/dashboard/dashboard.go
+package dashboard
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"github.com/sinaw369/Hermes/internal/message"
+	"github.com/sinaw369/Hermes/internal/state"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultConcurrency is the number of repositories read at once when CONCURRENCY is not set.
+const defaultConcurrency = 8
+
+// Columns of the table; the number keys 1 to 7 sort by them.
+const (
+	colRepository = iota
+	colBranch
+	colDirty
+	colAheadBehind
+	colLastCommit
+	colStashes
+	colLastSync
+)
+
+var columnTitles = []string{"Repository", "Branch", "Dirty", "Ahead/Behind", "Last commit", "Stashes", "Last sync"}
+
+var (
+	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF06B7"))
+	helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+)
+
+// UpdateMsg carries the repositories found by a refresh and then the status of each of them. The
+// TUI must deliver it to the dashboard even while another screen is shown.
+type UpdateMsg struct {
+	generation int
+	repos      []string
+	status     *client.RepoStatus
+	err        error
+}
+
+// syncResult is the outcome of a repository in the last "hermes sync" run.
+type syncResult struct {
+	text     string
+	finished time.Time
+}
+
+// lastSyncRun is the part of the sync status document the dashboard needs.
+type lastSyncRun struct {
+	LastRun *struct {
+		Dir      string    `json:"dir"`
+		Finished time.Time `json:"finished"`
+		Synced   []string  `json:"synced"`
+		Results  []struct {
+			Repository string `json:"repository"`
+			Error      string `json:"error"`
+		} `json:"results"`
+	} `json:"last_run"`
+}
+
+// Model lists the repositories under a directory with their git status, read in the background.
+type Model struct {
+	baseDir     string
+	concurrency int
+	store       *state.Store
+	logWriter   *logWriter.Logger
+
+	table      table.Model
+	repos      []string                      // paths in the order found
+	statuses   map[string]*client.RepoStatus // by path; missing while being read
+	visible    []string                      // paths of the table rows
+	sortColumn int
+	sortDesc   bool
+	generation int
+	updates    chan UpdateMsg
+	lastSync   lastSyncRun
+	err        error
+
+	filter    textinput.Model
+	filtering bool
+
+	showingLog bool
+	logRepo    string
+	logView    viewport.Model
+
+	width  int
+	height int
+}
+
+// NewModel returns a dashboard of the repositories under baseDir. concurrency limits how many are
+// read at once; 0 uses the default.
+func NewModel(baseDir string, concurrency int, store *state.Store, width, height int, logger *logWriter.Logger) *Model {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "filter by repository or branch"
+
+	m := &Model{
+		baseDir:     baseDir,
+		concurrency: concurrency,
+		store:       store,
+		logWriter:   logger,
+		statuses:    make(map[string]*client.RepoStatus),
+		filter:      filter,
+		logView:     viewport.New(width, max(height-4, 5)),
+		width:       width,
+		height:      height,
+	}
+	m.table = table.New(table.WithFocused(true))
+	m.resize(width, height)
+	return m
+}
+
+// Init starts reading the repositories.
+func (m *Model) Init() tea.Cmd {
+	return m.refresh()
+}
+
+// refresh finds the repositories again and reads their status; results of earlier refreshes are dropped.
+func (m *Model) refresh() tea.Cmd {
+	m.generation++
+	m.err = nil
+	if err := m.store.Load(state.SyncStatusFile, &m.lastSync); err != nil {
+		m.lastSync = lastSyncRun{}
+	}
+	generation, baseDir := m.generation, m.baseDir
+	return func() tea.Msg {
+		if baseDir == "" {
+			return UpdateMsg{generation: generation, err: fmt.Errorf("WORKING_DIR is not set")}
+		}
+		repos, err := client.FindRepositories(baseDir)
+		return UpdateMsg{generation: generation, repos: repos, err: err}
+	}
+}
+
+// readStatuses reads the repositories with a pool of workers. The channel holds every result, so
+// workers never block when a later refresh stops listening.
+func (m *Model) readStatuses(repos []string) tea.Cmd {
+	m.updates = make(chan UpdateMsg, len(repos))
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < min(m.concurrency, len(repos)); i++ {
+		wg.Add(1)
+		go func(generation int, updates chan<- UpdateMsg) {
+			defer wg.Done()
+			for path := range jobs {
+				status := client.ReadRepoStatus(path)
+				updates <- UpdateMsg{generation: generation, status: &status}
+			}
+		}(m.generation, m.updates)
+	}
+	go func(updates chan UpdateMsg) {
+		for _, path := range repos {
+			jobs <- path
+		}
+		close(jobs)
+		wg.Wait()
+		close(updates)
+	}(m.updates)
+	return m.listen()
+}
+
+// listen waits for the next status.
+func (m *Model) listen() tea.Cmd {
+	updates := m.updates
+	return func() tea.Msg {
+		update, ok := <-updates
+		if !ok {
+			return nil
+		}
+		return update
+	}
+}
+
+// Update handles the results of the background reads and the keys of the table, filter and log view.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case UpdateMsg:
+		if msg.generation != m.generation {
+			return m, nil
+		}
+		switch {
+		case msg.err != nil:
+			m.err = msg.err
+		case msg.status != nil:
+			m.statuses[msg.status.Path] = msg.status
+			m.updateRows()
+			return m, m.listen()
+		default:
+			m.repos = msg.repos
+			m.statuses = make(map[string]*client.RepoStatus)
+			m.updateRows()
+			if len(msg.repos) > 0 {
+				return m, m.readStatuses(msg.repos)
+			}
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.showingLog {
+			switch msg.String() {
+			case "esc", "backspace", "q":
+				m.showingLog = false
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.logView, cmd = m.logView.Update(msg)
+			return m, cmd
+		}
+		if m.filtering {
+			switch msg.String() {
+			case "enter":
+				m.filtering = false
+				m.filter.Blur()
+			case "esc":
+				m.filtering = false
+				m.filter.Blur()
+				m.filter.SetValue("")
+			default:
+				var cmd tea.Cmd
+				m.filter, cmd = m.filter.Update(msg)
+				m.updateRows()
+				return m, cmd
+			}
+			m.updateRows()
+			return m, nil
+		}
+
+		switch key := msg.String(); key {
+		case "esc":
+			if m.filter.Value() != "" {
+				m.filter.SetValue("")
+				m.updateRows()
+				return m, nil
+			}
+			return m, func() tea.Msg { return message.BackMsg{} }
+		case "ctrl+c":
+			return m, tea.Quit
+		case "r":
+			return m, m.refresh()
+		case "/":
+			m.filtering = true
+			return m, m.filter.Focus()
+		case "1", "2", "3", "4", "5", "6", "7":
+			column := int(key[0] - '1')
+			if column == m.sortColumn {
+				m.sortDesc = !m.sortDesc
+			} else {
+				m.sortColumn, m.sortDesc = column, false
+			}
+			m.updateRows()
+			return m, nil
+		case "enter":
+			if path := m.selected(); path != "" {
+				return m, func() tea.Msg { return message.GitRepoMsg{Path: path} }
+			}
+			return m, nil
+		case "l":
+			if path := m.selected(); path != "" {
+				m.showLog(path)
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+// selected returns the path of the highlighted repository.
+func (m *Model) selected() string {
+	if cursor := m.table.Cursor(); cursor >= 0 && cursor < len(m.visible) {
+		return m.visible[cursor]
+	}
+	return ""
+}
+
+// showLog shows the recent commits of the current branch of the repository.
+func (m *Model) showLog(path string) {
+	out, err := exec.Command("git", "-C", path, "log", "--oneline", "--decorate", "-n", "200").CombinedOutput()
+	content := string(out)
+	if err != nil {
+		content = errorStyle.Render(fmt.Sprintf("git log: %v", err)) + "\n" + content
+	}
+	m.logRepo = path
+	m.logView.SetContent(content)
+	m.logView.GotoTop()
+	m.showingLog = true
+}
+
+// resize fits the table and the log view to the window; the repository column takes the free width.
+func (m *Model) resize(width, height int) {
+	m.width, m.height = width, height
+	widths := []int{0, 20, 6, 13, 12, 8, 16}
+	repoWidth := width - 2*len(widths)
+	for _, w := range widths[1:] {
+		repoWidth -= w
+	}
+	widths[colRepository] = max(repoWidth, 20)
+	columns := make([]table.Column, len(columnTitles))
+	for i, title := range columnTitles {
+		columns[i] = table.Column{Title: title, Width: widths[i]}
+	}
+	m.table.SetColumns(columns)
+	m.table.SetHeight(max(height-6, 5))
+	m.logView.Width = width
+	m.logView.Height = max(height-4, 5)
+}
+
+// updateRows filters and sorts the repositories and keeps the cursor on the same repository.
+func (m *Model) updateRows() {
+	current := m.selected()
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+
+	m.visible = m.visible[:0]
+	for _, path := range m.repos {
+		if query != "" {
+			text := strings.ToLower(m.name(path))
+			if status := m.statuses[path]; status != nil {
+				text += " " + strings.ToLower(status.Branch)
+			}
+			if !strings.Contains(text, query) {
+				continue
+			}
+		}
+		m.visible = append(m.visible, path)
+	}
+	slices.SortStableFunc(m.visible, func(a, b string) int {
+		c := m.compare(a, b)
+		if m.sortDesc {
+			c = -c
+		}
+		return c
+	})
+
+	rows := make([]table.Row, 0, len(m.visible))
+	cursor := 0
+	for i, path := range m.visible {
+		rows = append(rows, m.row(path))
+		if path == current {
+			cursor = i
+		}
+	}
+	m.table.SetRows(rows)
+	m.table.SetCursor(cursor)
+}
+
+// compare orders two repositories by the sort column; repositories still being read come last.
+func (m *Model) compare(a, b string) int {
+	sa, sb := m.statuses[a], m.statuses[b]
+	if m.sortColumn != colRepository && (sa == nil || sb == nil) {
+		switch {
+		case sa == nil && sb == nil:
+			return cmp.Compare(m.name(a), m.name(b))
+		case sa == nil:
+			return 1
+		default:
+			return -1
+		}
+	}
+	switch m.sortColumn {
+	case colBranch:
+		return cmp.Compare(sa.Branch, sb.Branch)
+	case colDirty:
+		return cmp.Compare(sa.Dirty, sb.Dirty)
+	case colAheadBehind:
+		return cmp.Or(cmp.Compare(sa.Behind, sb.Behind), cmp.Compare(sa.Ahead, sb.Ahead))
+	case colLastCommit:
+		return sa.LastCommit.Compare(sb.LastCommit)
+	case colStashes:
+		return cmp.Compare(sa.Stashes, sb.Stashes)
+	case colLastSync:
+		return m.syncResult(sa).finished.Compare(m.syncResult(sb).finished)
+	}
+	return cmp.Compare(m.name(a), m.name(b))
+}
+
+// name is the path of the repository relative to the base directory.
+func (m *Model) name(path string) string {
+	if rel, err := filepath.Rel(m.baseDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// row renders the columns of a repository.
+func (m *Model) row(path string) table.Row {
+	status := m.statuses[path]
+	if status == nil {
+		return table.Row{m.name(path), "...", "", "", "", "", ""}
+	}
+	if status.Err != nil {
+		return table.Row{m.name(path), "error", "", "", "", "", ""}
+	}
+
+	branch := status.Branch
+	if branch == "" {
+		branch = "(detached)"
+	}
+	aheadBehind := "no upstream"
+	if status.Upstream != "" {
+		aheadBehind = fmt.Sprintf("↑%d ↓%d", status.Ahead, status.Behind)
+	}
+	lastCommit := "-"
+	if !status.LastCommit.IsZero() {
+		lastCommit = client.FormatAge(status.LastCommit) + " ago"
+	}
+	return table.Row{
+		m.name(path),
+		branch,
+		fmt.Sprint(status.Dirty),
+		aheadBehind,
+		lastCommit,
+		fmt.Sprint(status.Stashes),
+		m.syncResult(status).text,
+	}
+}
+
+// syncResult finds the repository in the last sync run, which lists the repositories it synced
+// and the results of those that failed or need attention. Runs of older versions do not list the
+// synced repositories; there a repository under the run's directory is assumed to be synced.
+func (m *Model) syncResult(status *client.RepoStatus) syncResult {
+	run := m.lastSync.LastRun
+	if run == nil || run.Dir == "" {
+		return syncResult{text: "-"}
+	}
+	if rel, err := filepath.Rel(run.Dir, status.Path); err != nil || strings.HasPrefix(rel, "..") {
+		return syncResult{text: "-"}
+	}
+	if run.Synced != nil && (status.Remote == "" || !slices.Contains(run.Synced, status.Remote)) {
+		return syncResult{text: "not in last run"}
+	}
+	result := syncResult{text: "ok " + client.FormatAge(run.Finished) + " ago", finished: run.Finished}
+	for _, repo := range run.Results {
+		if status.Remote == "" || repo.Repository != status.Remote {
+			continue
+		}
+		if repo.Error != "" {
+			result.text = "failed " + client.FormatAge(run.Finished) + " ago"
+		} else {
+			result.text = "warnings " + client.FormatAge(run.Finished) + " ago"
+		}
+	}
+	return result
+}
+
+// View renders the table, or the log of a repository.
+func (m *Model) View() string {
+	if m.showingLog {
+		header := titleStyle.Render("Log of "+m.name(m.logRepo)) + helpStyle.Render("  (Esc to go back)")
+		return header + "\n\n" + m.logView.View()
+	}
+
+	var view strings.Builder
+	view.WriteString(titleStyle.Render("Repositories in " + m.baseDir))
+	if read := len(m.statuses); read < len(m.repos) {
+		view.WriteString(helpStyle.Render(fmt.Sprintf("  reading %d/%d...", read, len(m.repos))))
+	} else {
+		view.WriteString(helpStyle.Render(fmt.Sprintf("  %d repositories", len(m.repos))))
+	}
+	direction := "ascending"
+	if m.sortDesc {
+		direction = "descending"
+	}
+	view.WriteString(helpStyle.Render(fmt.Sprintf(", sorted by %s (%s)", strings.ToLower(columnTitles[m.sortColumn]), direction)))
+	view.WriteString("\n")
+	if m.filtering || m.filter.Value() != "" {
+		view.WriteString(m.filter.View())
+	}
+	view.WriteString("\n")
+
+	if m.err != nil {
+		view.WriteString(errorStyle.Render(m.err.Error()) + "\n")
+	}
+	view.WriteString(m.table.View() + "\n")
+	if status := m.statuses[m.selected()]; status != nil && status.Err != nil {
+		view.WriteString(errorStyle.Render(status.Err.Error()) + "\n")
+	}
+	view.WriteString(helpStyle.Render("Enter: diff, l: log, r: refresh, /: filter, 1-7: sort by column (again to reverse), Esc: back"))
+	return view.String()
+}
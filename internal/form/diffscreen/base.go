@@ -3,17 +3,36 @@ package diffscreen
 import (
 	"bytes"
 	"fmt"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sinaw369/Hermes/internal/client"
 	"github.com/sinaw369/Hermes/internal/color"
 	HermesMsg "github.com/sinaw369/Hermes/internal/message"
+	"github.com/sinaw369/Hermes/internal/state"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// view is what the diff screen shows.
+type view int
+
+const (
+	viewLog    view = iota // the commits between the branches
+	viewCommit             // the patch of the selected commit
+	viewBranch             // the aggregate diff between the branches, grouped by file
+)
+
+// commit is a commit listed between the branches.
+type commit struct {
+	hash    string
+	subject string
+	date    string
+}
+
 // DiffModel displays a unified diff between two branches.
 type Model struct {
 	viewport   viewport.Model
@@ -24,35 +43,141 @@ type Model struct {
 	width      int
 	height     int
 	err        error
+	view       view
+	commits    []commit
+	cursor     int   // selected commit in the log view
+	fileLines  []int // line of each file section in the branch diff view
+
+	// The configured branches are compared unless another comparison was chosen for the repository.
+	defaultFrom string
+	defaultTo   string
+	store       *state.Store // remembers the comparison chosen for each repository
+	picker      list.Model
+	picking     int
+	pickedFrom  string
+	notice      string
+
+	// fetch updates the compared refs from origin; autoFetch runs it whenever a diff is opened.
+	fetch     func(repoPath string, names ...string) error
+	autoFetch bool
+	fetching  bool
+	fetchErr  error
+	refsAge   string
+}
+
+// FetchedMsg is sent when the compared refs of a repository were fetched. It must reach the diff
+// view even when another screen is shown, or the view keeps waiting for the fetch.
+type FetchedMsg struct {
+	repoPath string
+	err      error
 }
 
-// NewDiffModel creates a new diff view.
-func NewDiffModel(width, height int, repoPath, branchFrom, branchTo string) *Model {
+// NewDiffModel creates a new diff view. The comparison last chosen for a repository is kept in store.
+func NewDiffModel(width, height int, repoPath, branchFrom, branchTo string, store *state.Store) *Model {
 	vp := viewport.New(width, height-3) // reserve some space for header
 	model := Model{
-		viewport:   vp,
-		branchFrom: branchFrom,
-		branchTo:   branchTo,
-		repoPath:   repoPath,
-		width:      width,
-		height:     height,
-		err:        nil,
+		viewport:    vp,
+		branchFrom:  branchFrom,
+		branchTo:    branchTo,
+		repoPath:    repoPath,
+		width:       width,
+		height:      height,
+		err:         nil,
+		defaultFrom: branchFrom,
+		defaultTo:   branchTo,
+		store:       store,
 	}
+	model.loadComparison()
 	model.fetchDiff() // populate diffContent
 	model.viewport.SetContent(model.content)
 	return &model
 }
+
+// SetBranches changes the configured branches, compared by the next fetch unless another
+// comparison was chosen for the repository.
+func (m *Model) SetBranches(branchFrom, branchTo string) {
+	m.defaultFrom = branchFrom
+	m.defaultTo = branchTo
+}
+
+// SetFetcher sets how the compared refs are fetched from origin, and whether that happens every
+// time a diff is opened rather than only when asked for with 'f'.
+func (m *Model) SetFetcher(fetch func(repoPath string, names ...string) error, auto bool) {
+	m.fetch, m.autoFetch = fetch, auto
+}
+
+// AutoFetch returns the command fetching the compared refs if they are fetched whenever a diff is opened.
+func (m *Model) AutoFetch() tea.Cmd {
+	if !m.autoFetch {
+		return nil
+	}
+	return m.fetchRefs()
+}
+
+// fetchRefs fetches the compared refs in the background; the diff is refreshed once they arrive.
+func (m *Model) fetchRefs() tea.Cmd {
+	if m.fetch == nil || m.fetching {
+		return nil
+	}
+	m.fetching, m.fetchErr = true, nil
+	fetch, repoPath, names := m.fetch, m.repoPath, []string{m.branchFrom, m.branchTo}
+	return func() tea.Msg {
+		return FetchedMsg{repoPath: repoPath, err: fetch(repoPath, names...)}
+	}
+}
+
+// UpdateFetch shows the diff of the repository. A fetch of the same repository that is still
+// running is kept, so its result refreshes the diff when it arrives.
 func (m *Model) UpdateFetch(repoPath string) {
-	m.repoPath = repoPath
+	if repoPath != m.repoPath {
+		m.fetching = false
+	}
+	m.repoPath, m.fetchErr = repoPath, nil
+	m.loadComparison()
 	m.fetchDiff() // populate diffContent
 	m.viewport.SetContent(m.content)
+	m.viewport.GotoTop()
 }
 
-// fetchDiff runs "git diff branchFrom.branchTo" and stores the output.
+// loadComparison compares the branches or tags last chosen for the repository, or else the configured branches.
+func (m *Model) loadComparison() {
+	m.branchFrom, m.branchTo = m.defaultFrom, m.defaultTo
+	if m.store == nil {
+		return
+	}
+	if comparison, ok, err := m.store.Comparison(m.repoPath); err == nil && ok {
+		m.branchFrom, m.branchTo = comparison.From, comparison.To
+	}
+}
+
+// compare shows the diff between two branches or tags and remembers them for the repository.
+// Comparing the configured branches forgets the remembered comparison.
+func (m *Model) compare(from, to string) {
+	m.branchFrom, m.branchTo = from, to
+	if m.store != nil {
+		var err error
+		if from == m.defaultFrom && to == m.defaultTo {
+			err = m.store.ForgetComparison(m.repoPath)
+		} else {
+			err = m.store.SaveComparison(m.repoPath, from, to)
+		}
+		if err != nil {
+			m.notice = err.Error()
+		}
+	}
+	m.fetchDiff()
+	m.viewport.SetContent(m.content)
+	m.viewport.GotoTop()
+}
+
+// fetchDiff runs "git log origin/branchFrom..origin/branchTo", stores the commits and shows them.
+// Tags are compared instead of branches when there is no such branch. If either does not exist,
+// the branch picker is opened.
 func (m *Model) fetchDiff() {
-	//cmd := exec.Command("git", "diff", m.branchFrom+"..origin/"+m.branchTo)
+	m.view, m.cursor, m.commits, m.fileLines, m.err = viewLog, 0, nil, nil, nil
+	m.refsAge = client.RefsAge(m.repoPath)
 	//git log origin/production..origin/develop --oneline
-	cmd := exec.Command("git", "log", "--pretty=format:%H - %s - %ai - %ar", "origin/"+m.branchFrom+"..origin/"+m.branchTo)
+	cmd := exec.Command("git", "log", "--pretty=format:%H - %s - %ai - %ar", m.revision(m.branchFrom)+".."+m.revision(m.branchTo))
 	cmd.Dir = m.repoPath
 
 	var out bytes.Buffer
@@ -61,52 +186,164 @@ func (m *Model) fetchDiff() {
 	if err != nil {
 		m.err = fmt.Errorf("error running git log: %v", err)
 		m.content = m.err.Error()
+		if missing := m.missingRef(); missing != "" {
+			m.content = fmt.Sprintf("%s does not exist in %s; press b to choose the branches or tags to compare", missing, m.repoPath)
+			m.openPicker(fmt.Sprintf("%s does not exist in this repository; choose the branches or tags to compare", missing))
+		}
 		return
 	}
 
-	output := strings.TrimSpace(out.String())
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		commitParts := strings.SplitN(line, " - ", 4) // Ensure correct splitting
+		if len(commitParts) < 4 {
+			continue // Skip if not properly formatted
+		}
+		m.commits = append(m.commits, commit{
+			hash:    commitParts[0],
+			subject: strings.TrimSpace(commitParts[1]),
+			date:    strings.TrimSpace(commitParts[2]),
+		})
+	}
+	m.renderLog()
+}
 
-	if output == "" {
+// renderLog shows the commits, marking the selected one.
+func (m *Model) renderLog() {
+	if len(m.commits) == 0 {
 		m.content = "No differences between " + m.branchFrom + " and " + m.branchTo
 		return
 	}
 
-	lines := strings.Split(output, "\n")
-
 	// Define styles
 	headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("201"))
 	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(color.NewColors().NeonMagenta.Hex))
+	cursorStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
 
 	// Generate commit count header
-	diffCount := strings.TrimSpace(headerStyle.Render("Number of different commits: " + strconv.Itoa(len(lines)) + "\n"))
+	diffCount := headerStyle.Render("Number of different commits: " + strconv.Itoa(len(m.commits)))
 
-	// Format commits as "1. full_commit_hash - commit_msg - YYYY-MM-DD - relative_time"
-	var formattedLines []string
+	// Format commits as "1. commit_msg , full_commit_hash , YYYY-MM-DD HH:MM:SS"
+	formattedLines := []string{diffCount}
 	separator := separatorStyle.Render(" , ")
+	for i, c := range m.commits {
+		formattedCommit := fmt.Sprintf("%d. %s%s%s%s%s", i+1, c.subject, separator, c.hash, separator, c.date)
+		if i == m.cursor {
+			formattedCommit = cursorStyle.Render("> ") + formattedCommit
+		} else {
+			formattedCommit = "  " + formattedCommit
+		}
+		formattedLines = append(formattedLines, formattedCommit)
+	}
 
-	for i, line := range lines {
-		commitParts := strings.SplitN(line, " - ", 4) // Ensure correct splitting
-		if len(commitParts) < 4 {
-			continue // Skip if not properly formatted
+	// Join formatted commits and store in m.content
+	m.content = strings.Join(formattedLines, "\n")
+}
+
+// moveCursor selects another commit in the log view and keeps it visible.
+func (m *Model) moveCursor(delta int) {
+	if len(m.commits) == 0 {
+		return
+	}
+	m.cursor = max(0, min(len(m.commits)-1, m.cursor+delta))
+	m.renderLog()
+	m.viewport.SetContent(m.content)
+	line := m.cursor + 1 // below the commit count
+	if line < m.viewport.YOffset {
+		m.viewport.SetYOffset(line)
+	} else if line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height + 1)
+	}
+}
+
+// showCommit shows the statistics and the patch of the selected commit.
+func (m *Model) showCommit() {
+	if len(m.commits) == 0 {
+		return
+	}
+	c := m.commits[m.cursor]
+	out, err := git(m.repoPath, "show", "--stat", "--patch", "--format=commit %H%nAuthor: %an <%ae>%nDate:   %ai%n%n    %s%n%n%b", c.hash)
+	if err != nil {
+		out = err.Error()
+	}
+	m.view = viewCommit
+	m.fileLines = nil
+	for i, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			m.fileLines = append(m.fileLines, i)
 		}
+	}
+	m.viewport.SetContent(colorizeDiff(strings.TrimRight(out, "\n")))
+	m.viewport.GotoTop()
+}
 
-		commitHash := commitParts[0]
-		commitMessage := strings.TrimSpace(commitParts[1])
-		fullDate := strings.TrimSpace(commitParts[2])
-		// commitRelativeTime := strings.TrimSpace(commitParts[3])
+// showBranchDiff shows the aggregate diff between the branches: the totals, a tree of the changed
+// files and the patch of each file. Like the log, it compares branchTo with its merge base with branchFrom.
+func (m *Model) showBranchDiff() {
+	m.view = viewBranch
+	m.fileLines = nil
+	rangeArg := m.revision(m.branchFrom) + "..." + m.revision(m.branchTo)
+	content, err := m.branchDiff(rangeArg)
+	if err != nil {
+		content = err.Error()
+	}
+	m.viewport.SetContent(content)
+	m.viewport.GotoTop()
+}
 
-		// Extract only the date (YYYY-MM-DD) from the full timestamp
-		//dateOnly := strings.Split(fullDate, " ")[0]
+// branchDiff renders the aggregate diff of the range and records where each file starts.
+func (m *Model) branchDiff(rangeArg string) (string, error) {
+	shortstat, err := git(m.repoPath, "diff", "--no-renames", "--shortstat", rangeArg)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(shortstat) == "" {
+		return "No changes between " + m.branchFrom + " and " + m.branchTo, nil
+	}
+	numstat, err := git(m.repoPath, "diff", "--no-renames", "--numstat", rangeArg)
+	if err != nil {
+		return "", err
+	}
+	patch, err := git(m.repoPath, "diff", "--no-renames", rangeArg)
+	if err != nil {
+		return "", err
+	}
 
-		// Format the commit line properly
-		formattedCommit := fmt.Sprintf("%d. %s%s%s%s%s%s",
-			i+1, commitMessage, separator, commitHash, separator, fullDate, separator)
+	lines := []string{metaStyle.Render(strings.TrimSpace(shortstat)), ""}
+	lines = append(lines, fileTree(parseNumstat(numstat))...)
+	for _, part := range splitPatch(patch) {
+		lines = append(lines, "")
+		m.fileLines = append(m.fileLines, len(lines))
+		lines = append(lines, fileStyle.Render("▌ "+patchFile(part)))
+		lines = append(lines, strings.Split(colorizeDiff(part), "\n")...)
+	}
+	return strings.Join(lines, "\n"), nil
+}
 
-		formattedLines = append(formattedLines, formattedCommit)
+// jumpToFile scrolls the branch diff to the next or previous file.
+func (m *Model) jumpToFile(next bool) {
+	offset := m.viewport.YOffset
+	if next {
+		for _, line := range m.fileLines {
+			if line > offset {
+				m.viewport.SetYOffset(line)
+				return
+			}
+		}
+		return
+	}
+	for i := len(m.fileLines) - 1; i >= 0; i-- {
+		if m.fileLines[i] < offset {
+			m.viewport.SetYOffset(m.fileLines[i])
+			return
+		}
 	}
+}
 
-	// Join formatted commits and store in m.content
-	m.content = diffCount + strings.Join(formattedLines, "\n")
+// backToLog returns from a patch to the list of commits.
+func (m *Model) backToLog() {
+	m.view, m.fileLines = viewLog, nil
+	m.viewport.SetContent(m.content)
+	m.moveCursor(0)
 }
 
 // colorizeDiff applies basic colorization to a diff string.
@@ -115,10 +352,14 @@ func colorizeDiff(diff string) string {
 	var coloredLines []string
 	for _, line := range lines {
 		switch {
-		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
-			coloredLines = append(coloredLines, lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render(line))
-		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
-			coloredLines = append(coloredLines, lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(line))
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") || strings.HasPrefix(line, "diff --git"):
+			coloredLines = append(coloredLines, metaStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			coloredLines = append(coloredLines, addedStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			coloredLines = append(coloredLines, removedStyle.Render(line))
+		case strings.HasPrefix(line, "@@"):
+			coloredLines = append(coloredLines, hunkStyle.Render(line))
 		default:
 			coloredLines = append(coloredLines, line)
 		}
@@ -136,11 +377,46 @@ func (m *Model) Init() tea.Cmd {
 
 type tickMsg time.Time
 
-// Update handles key events for scrolling.
+// Update handles key events for scrolling. In the log view the arrows select a commit, Enter shows
+// it and 't' toggles the aggregate diff between the branches.
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(FetchedMsg); ok {
+		if msg.repoPath != m.repoPath || !m.fetching {
+			return m, nil
+		}
+		m.fetching, m.fetchErr = false, msg.err
+		if msg.err == nil && m.picking == pickNone {
+			m.fetchDiff()
+			m.viewport.SetContent(m.content)
+			m.viewport.GotoTop()
+		}
+		return m, nil
+	}
+	if _, ok := msg.(tea.WindowSizeMsg); !ok && m.picking != pickNone {
+		return m.updatePicker(msg)
+	}
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.view == viewLog {
+			switch msg.String() {
+			case "up", "k":
+				m.moveCursor(-1)
+				return m, nil
+			case "down", "j":
+				m.moveCursor(1)
+				return m, nil
+			case "pgup":
+				m.moveCursor(-m.viewport.Height)
+				return m, nil
+			case "pgdown":
+				m.moveCursor(m.viewport.Height)
+				return m, nil
+			case "enter":
+				m.showCommit()
+				return m, nil
+			}
+		}
 		switch msg.String() {
 		case "up", "k":
 			m.viewport.LineUp(1)
@@ -150,7 +426,35 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewport.ViewUp()
 		case "pgdown":
 			m.viewport.ViewDown()
+		case "n":
+			m.jumpToFile(true)
+			return m, nil
+		case "p":
+			m.jumpToFile(false)
+			return m, nil
+		case "b":
+			m.openPicker("")
+			return m, nil
+		case "f":
+			return m, m.fetchRefs()
+		case "s":
+			m.compare(m.branchTo, m.branchFrom)
+			return m, nil
+		case "d":
+			m.compare(m.defaultFrom, m.defaultTo)
+			return m, nil
+		case "t":
+			if m.view == viewBranch {
+				m.backToLog()
+			} else {
+				m.showBranchDiff()
+			}
+			return m, nil
 		case "backspace":
+			if m.view != viewLog {
+				m.backToLog()
+				return m, nil
+			}
 			return m, func() tea.Msg { return HermesMsg.BackToFolderMsg{} }
 		case "q", "ctrl+c":
 			return m, tea.Quit
@@ -160,6 +464,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		m.viewport.Width = msg.Width
 		m.viewport.Height = msg.Height - 3
+		m.picker.SetSize(msg.Width, max(msg.Height-4, 5))
 	}
 	m.viewport, cmd = m.viewport.Update(msg)
 	return m, cmd
@@ -167,9 +472,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View renders the diff screen.
 func (m *Model) View() string {
+	if m.picking != pickNone {
+		return m.pickerView()
+	}
+	var help string
+	switch m.view {
+	case viewLog:
+		help = "enter: show commit, t: diff by file, b: choose branches, s: swap, d: configured branches, f: fetch, backspace: folder list, q: quit"
+	case viewCommit:
+		help = "n/p: next/previous file, backspace: commits, t: diff by file, q: quit"
+	case viewBranch:
+		help = "n/p: next/previous file, t or backspace: commits, q: quit"
+	}
 	header := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#FF06B7")).
-		Render(fmt.Sprintf("Diff: %s..%s (press q to quit,backspace to folder list)", m.branchFrom, m.branchTo))
-	return fmt.Sprintf("%s\n%s", header, m.viewport.View())
+		Render(fmt.Sprintf("Diff: %s..%s", m.branchFrom, m.branchTo)) + "  " + dimStyle.Render(help)
+	status := dimStyle.Render(m.refsAge)
+	switch {
+	case m.fetching:
+		status = dimStyle.Render("fetching " + m.branchFrom + " and " + m.branchTo + " from origin...")
+	case m.fetchErr != nil:
+		status = noticeStyle.Render("fetch failed: "+m.fetchErr.Error()) + "  " + status
+	}
+	return fmt.Sprintf("%s\n%s\n%s", header, status, m.viewport.View())
 }
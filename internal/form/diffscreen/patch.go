@@ -0,0 +1,109 @@
+package diffscreen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+)
+
+var (
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	metaStyle    = lipgloss.NewStyle().Bold(true)
+	fileStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF06B7"))
+	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+)
+
+// fileStat is a file changed between the branches, as reported by "git diff --numstat".
+type fileStat struct {
+	path    string
+	added   string // "-" for binary files
+	deleted string
+}
+
+// git runs git in the repository and returns its output; the error includes what git printed.
+func git(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("error running git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("error running git %s: %v", args[0], err)
+	}
+	return out.String(), nil
+}
+
+// parseNumstat parses the output of "git diff --numstat".
+func parseNumstat(out string) []fileStat {
+	var files []fileStat
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		files = append(files, fileStat{path: fields[2], added: fields[0], deleted: fields[1]})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files
+}
+
+// fileTree renders the changed files as a directory tree with their added and deleted lines.
+func fileTree(files []fileStat) []string {
+	var lines []string
+	var prev []string
+	for _, f := range files {
+		dirs := strings.Split(path.Dir(f.path), "/")
+		if dirs[0] == "." {
+			dirs = nil
+		}
+		common := 0
+		for common < len(dirs) && common < len(prev) && dirs[common] == prev[common] {
+			common++
+		}
+		for i := common; i < len(dirs); i++ {
+			lines = append(lines, strings.Repeat("  ", i)+dirs[i]+"/")
+		}
+		counts := "binary"
+		if f.added != "-" {
+			counts = addedStyle.Render("+"+f.added) + " " + removedStyle.Render("-"+f.deleted)
+		}
+		lines = append(lines, strings.Repeat("  ", len(dirs))+path.Base(f.path)+"  "+counts)
+		prev = dirs
+	}
+	return lines
+}
+
+// splitPatch splits a patch into the parts of each file, each starting with its "diff --git" line.
+func splitPatch(patch string) []string {
+	var parts []string
+	var current []string
+	for _, line := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") && len(current) > 0 {
+			parts = append(parts, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		parts = append(parts, strings.Join(current, "\n"))
+	}
+	return parts
+}
+
+// patchFile returns the path of the file a part of a patch changes.
+func patchFile(part string) string {
+	header, _, _ := strings.Cut(part, "\n")
+	if _, b, ok := strings.Cut(header, " b/"); ok {
+		return b
+	}
+	return strings.TrimPrefix(header, "diff --git ")
+}
@@ -0,0 +1,53 @@
+package diffscreen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNumstat(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []fileStat
+	}{
+		{
+			name: "empty",
+			out:  "",
+			want: nil,
+		},
+		{
+			name: "sorted by path",
+			out:  "3\t1\tmain.go\n10\t0\tinternal/client/diff.go\n",
+			want: []fileStat{
+				{path: "internal/client/diff.go", added: "10", deleted: "0"},
+				{path: "main.go", added: "3", deleted: "1"},
+			},
+		},
+		{
+			name: "binary file",
+			out:  "-\t-\tlogo.png",
+			want: []fileStat{{path: "logo.png", added: "-", deleted: "-"}},
+		},
+		{
+			name: "path with tab and rename",
+			out:  "1\t1\tdocs/{old.md => new.md}\n2\t0\tname\twith tab.txt",
+			want: []fileStat{
+				{path: "docs/{old.md => new.md}", added: "1", deleted: "1"},
+				{path: "name\twith tab.txt", added: "2", deleted: "0"},
+			},
+		},
+		{
+			name: "malformed lines are skipped",
+			out:  "warning: something\n4\t2\tREADME.md",
+			want: []fileStat{{path: "README.md", added: "4", deleted: "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNumstat(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNumstat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
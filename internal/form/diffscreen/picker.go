@@ -0,0 +1,148 @@
+package diffscreen
+
+import (
+	"fmt"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sinaw369/Hermes/internal/client"
+	"strings"
+)
+
+// Steps of the branch picker.
+const (
+	pickNone = iota
+	pickFrom
+	pickTo
+)
+
+var noticeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+// refItem is a remote branch or a tag offered by the branch picker.
+type refItem struct {
+	name string
+	tag  bool
+}
+
+func (r refItem) Title() string { return r.name }
+func (r refItem) Description() string {
+	if r.tag {
+		return "tag"
+	}
+	return "remote branch"
+}
+func (r refItem) FilterValue() string { return r.name }
+
+// listRefs returns the remote branches of origin, sorted by name, followed by the tags, newest first.
+func listRefs(repoPath string) ([]list.Item, error) {
+	branches, err := git(repoPath, "for-each-ref", "--format=%(refname:short)", "refs/remotes/origin")
+	if err != nil {
+		return nil, err
+	}
+	tags, err := git(repoPath, "for-each-ref", "--sort=-creatordate", "--format=%(refname:short)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	var items []list.Item
+	for _, branch := range strings.Fields(branches) {
+		if name, ok := strings.CutPrefix(branch, "origin/"); ok && name != "HEAD" {
+			items = append(items, refItem{name: name})
+		}
+	}
+	for _, tag := range strings.Fields(tags) {
+		items = append(items, refItem{name: tag, tag: true})
+	}
+	return items, nil
+}
+
+// refExists reports whether the ref exists in the repository.
+func refExists(repoPath, ref string) bool {
+	_, err := git(repoPath, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// revision returns the revision git compares for a name from the picker: the remote branch of
+// origin with that name, or else the tag.
+func (m *Model) revision(name string) string {
+	return client.Revision(m.repoPath, name)
+}
+
+// missingRef returns the name of the compared branch or tag that does not exist, if any.
+func (m *Model) missingRef() string {
+	for _, name := range []string{m.branchFrom, m.branchTo} {
+		if !refExists(m.repoPath, m.revision(name)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// openPicker lets the user choose the ref to compare from, then the one to compare to.
+// notice explains why the picker was opened, if it was not asked for.
+func (m *Model) openPicker(notice string) {
+	items, err := listRefs(m.repoPath)
+	if err != nil {
+		notice = err.Error()
+	}
+	delegate := list.NewDefaultDelegate()
+	delegate.SetSpacing(0)
+	m.picker = list.New(items, delegate, m.width, max(m.height-4, 5))
+	m.picker.SetShowStatusBar(false)
+	m.picker.SetFilteringEnabled(true)
+	m.picking = pickFrom
+	m.notice = notice
+	m.picker.Title = fmt.Sprintf("Compare from (now %s)", m.branchFrom)
+	m.selectRef(m.branchFrom)
+}
+
+// selectRef moves the picker's cursor to the named ref.
+func (m *Model) selectRef(name string) {
+	for i, item := range m.picker.Items() {
+		if item.(refItem).name == name {
+			m.picker.Select(i)
+			return
+		}
+	}
+	m.picker.Select(0)
+}
+
+// updatePicker handles the keys of the branch picker.
+func (m *Model) updatePicker(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && m.picker.FilterState() != list.Filtering {
+		switch key.String() {
+		case "esc", "backspace":
+			m.picking, m.notice = pickNone, ""
+			return m, nil
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			item, ok := m.picker.SelectedItem().(refItem)
+			if !ok {
+				return m, nil
+			}
+			if m.picking == pickFrom {
+				m.pickedFrom = item.name
+				m.picking = pickTo
+				m.picker.Title = fmt.Sprintf("Compare %s to (now %s)", item.name, m.branchTo)
+				m.picker.ResetFilter()
+				m.selectRef(m.branchTo)
+				return m, nil
+			}
+			m.picking, m.notice = pickNone, ""
+			m.compare(m.pickedFrom, item.name)
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.picker, cmd = m.picker.Update(msg)
+	return m, cmd
+}
+
+// pickerView renders the branch picker.
+func (m *Model) pickerView() string {
+	view := "\n"
+	if m.notice != "" {
+		view += noticeStyle.Render(m.notice) + "\n"
+	}
+	return view + m.picker.View() + "\n" + dimStyle.Render("enter: choose, /: filter, esc: cancel")
+}
@@ -0,0 +1,412 @@
+// File: forms/initScreen/initScreen.go
+package initScreen
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/form/screen"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"github.com/sinaw369/Hermes/internal/message"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Labels of the wizard's form fields.
+const (
+	FieldProfile    = "Profile name"
+	FieldBaseURL    = "GitLab URL"
+	FieldToken      = "Access token"
+	FieldWorkingDir = "Working directory"
+	FieldBranchFrom = "Diff branch from"
+	FieldBranchTo   = "Diff branch to"
+)
+
+// groupsPageSize is the number of groups shown at once.
+const groupsPageSize = 15
+
+type step int
+
+const (
+	stepInstance step = iota
+	stepVerifying
+	stepGroups
+	stepWorkspace
+	stepDone
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF06B7"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	cursorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF06B7")).Bold(true)
+)
+
+// VerifyFunc checks the token against the GitLab instance and returns the user name and the full
+// paths of the user's groups.
+type VerifyFunc func(baseURL, token string) (username string, groups []string, err error)
+
+// Defaults prefill the wizard, e.g. from an existing configuration.
+type Defaults struct {
+	Profile    string
+	BaseURL    string
+	WorkingDir string
+	BranchFrom string
+	BranchTo   string
+}
+
+// Result is what the user entered once the wizard is complete.
+type Result struct {
+	Profile    string
+	BaseURL    string
+	Token      string
+	Username   string
+	Groups     []string // selected groups; empty means all projects
+	WorkingDir string
+	BranchFrom string
+	BranchTo   string
+}
+
+// verifiedMsg carries the outcome of the token check.
+type verifiedMsg struct {
+	username string
+	groups   []string
+	err      error
+}
+
+// Model is the state of the init wizard: the GitLab instance and token, the groups to sync and the workspace.
+type Model struct {
+	step          step
+	instanceForm  *screen.Model
+	workspaceForm *screen.Model
+	spinner       spinner.Model
+	verify        VerifyFunc
+	username      string
+	groups        []string
+	selected      map[string]bool
+	cursor        int
+	offset        int
+	err           error
+	Result        *Result // set once the wizard is complete
+}
+
+// NewModel returns the wizard, prefilled with the defaults.
+func NewModel(defaults Defaults, verify VerifyFunc, logger *logWriter.Logger) *Model {
+	instanceForm := screen.NewModel([]screen.ButtonModel{
+		{Label: FieldProfile, PlaceHolder: "work", Width: 50, Validate: validateProfile},
+		{Label: FieldBaseURL, PlaceHolder: "https://gitlab.example.com", Width: 50, Validate: validateURL},
+		{Label: FieldToken, PlaceHolder: "personal access token with the api scope", Width: 50, Validate: validateToken},
+	}, logger)
+	instanceForm.Inputs[0].Input.SetValue(defaults.Profile)
+	instanceForm.Inputs[1].Input.SetValue(defaults.BaseURL)
+	// The token is never echoed; screen.Model logs the collected values, so logger should discard them.
+	instanceForm.Inputs[2].Input.EchoMode = textinput.EchoPassword
+
+	workspaceForm := screen.NewModel([]screen.ButtonModel{
+		{Label: FieldWorkingDir, PlaceHolder: "/home/username/gitlab", Width: 50, Validate: validateWorkingDir},
+		{Label: FieldBranchFrom, PlaceHolder: "production", Width: 50, Validate: validateBranch},
+		{Label: FieldBranchTo, PlaceHolder: "develop", Width: 50, Validate: validateBranch},
+	}, logger)
+	workspaceForm.Inputs[0].Input.SetValue(defaults.WorkingDir)
+	workspaceForm.Inputs[1].Input.SetValue(defaults.BranchFrom)
+	workspaceForm.Inputs[2].Input.SetValue(defaults.BranchTo)
+
+	s := spinner.New()
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+
+	return &Model{
+		step:          stepInstance,
+		instanceForm:  instanceForm,
+		workspaceForm: workspaceForm,
+		spinner:       s,
+		verify:        verify,
+		selected:      make(map[string]bool),
+	}
+}
+
+// Init is the initialization command of the wizard.
+func (m *Model) Init() tea.Cmd {
+	return m.instanceForm.Init()
+}
+
+// Update moves through the wizard's steps.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case message.BackMsg:
+		return m.back()
+	case verifiedMsg:
+		return m.verified(msg)
+	case spinner.TickMsg:
+		if m.step == stepVerifying {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch m.step {
+	case stepInstance:
+		return m.updateInstance(msg)
+	case stepVerifying:
+		if key, ok := msg.(tea.KeyMsg); ok && key.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+	case stepGroups:
+		return m.updateGroups(msg)
+	case stepWorkspace:
+		return m.updateWorkspace(msg)
+	}
+	return m, nil
+}
+
+// back returns to the previous step; Esc on the first step leaves the wizard.
+func (m *Model) back() (tea.Model, tea.Cmd) {
+	m.err = nil
+	switch m.step {
+	case stepGroups:
+		m.step = stepInstance
+		m.instanceForm.Submitted = false
+	case stepWorkspace:
+		m.step = stepGroups
+	default:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *Model) updateInstance(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.instanceForm.Update(msg)
+	m.instanceForm = updated.(*screen.Model)
+	if !m.instanceForm.Submitted {
+		return m, cmd
+	}
+
+	// Check the token before going on.
+	values := m.instanceForm.GetValue().Strings()
+	baseURL := strings.TrimSuffix(strings.TrimSpace(values[FieldBaseURL]), "/")
+	token := strings.TrimSpace(values[FieldToken])
+	m.step = stepVerifying
+	m.err = nil
+	verify := m.verify
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		username, groups, err := verify(baseURL, token)
+		return verifiedMsg{username: username, groups: groups, err: err}
+	})
+}
+
+func (m *Model) verified(msg verifiedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		// Back to the form, so the URL or token can be corrected.
+		m.step = stepInstance
+		m.instanceForm.Submitted = false
+		m.err = msg.err
+		return m, nil
+	}
+	m.username = msg.username
+	m.groups = msg.groups
+	m.cursor, m.offset = 0, 0
+	m.step = stepGroups
+	return m, nil
+}
+
+func (m *Model) updateGroups(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		return m.back()
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.groups)-1 {
+			m.cursor++
+		}
+	case " ", "x":
+		if len(m.groups) > 0 {
+			group := m.groups[m.cursor]
+			m.selected[group] = !m.selected[group]
+		}
+	case "a":
+		// Select all groups, or none if all are selected.
+		all := len(m.selectedGroups()) == len(m.groups)
+		for _, group := range m.groups {
+			m.selected[group] = !all
+		}
+	case "enter":
+		m.step = stepWorkspace
+		m.workspaceForm.Submitted = false
+		return m, m.workspaceForm.Init()
+	}
+	// Keep the cursor on the visible page.
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+groupsPageSize {
+		m.offset = m.cursor - groupsPageSize + 1
+	}
+	return m, nil
+}
+
+func (m *Model) updateWorkspace(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.workspaceForm.Update(msg)
+	m.workspaceForm = updated.(*screen.Model)
+	if !m.workspaceForm.Submitted {
+		return m, cmd
+	}
+
+	instance := m.instanceForm.GetValue().Strings()
+	workspace := m.workspaceForm.GetValue().Strings()
+	workingDir, _ := expandHome(strings.TrimSpace(workspace[FieldWorkingDir]))
+	m.Result = &Result{
+		Profile:    strings.TrimSpace(instance[FieldProfile]),
+		BaseURL:    strings.TrimSuffix(strings.TrimSpace(instance[FieldBaseURL]), "/"),
+		Token:      strings.TrimSpace(instance[FieldToken]),
+		Username:   m.username,
+		Groups:     m.selectedGroups(),
+		WorkingDir: workingDir,
+		BranchFrom: strings.TrimSpace(workspace[FieldBranchFrom]),
+		BranchTo:   strings.TrimSpace(workspace[FieldBranchTo]),
+	}
+	m.step = stepDone
+	return m, tea.Quit
+}
+
+// selectedGroups returns the selected groups in the order they are listed.
+func (m *Model) selectedGroups() []string {
+	var groups []string
+	for _, group := range m.groups {
+		if m.selected[group] {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// View renders the current step.
+func (m *Model) View() string {
+	var view strings.Builder
+	view.WriteString(titleStyle.Render("Hermes setup") + "\n\n")
+
+	switch m.step {
+	case stepInstance:
+		view.WriteString("Step 1 of 3: GitLab instance and access token\n\n")
+		view.WriteString(m.instanceForm.View())
+	case stepVerifying:
+		view.WriteString(fmt.Sprintf("%s Checking the token with GitLab...\n", m.spinner.View()))
+	case stepGroups:
+		view.WriteString(fmt.Sprintf("Step 2 of 3: groups to sync (signed in as @%s)\n\n", m.username))
+		m.viewGroups(&view)
+	case stepWorkspace:
+		view.WriteString("Step 3 of 3: working directory and diff branches\n\n")
+		view.WriteString(m.workspaceForm.View())
+	case stepDone:
+		view.WriteString("Writing the configuration...\n")
+	}
+
+	if m.err != nil {
+		view.WriteString(errorStyle.Render(fmt.Sprintf("\n\n%v", m.err)))
+	}
+	return view.String()
+}
+
+func (m *Model) viewGroups(view *strings.Builder) {
+	if len(m.groups) == 0 {
+		view.WriteString("You are not a member of any group; all projects you can access will be synced.\n\n")
+		view.WriteString(helpStyle.Render("Press 'Enter' to continue, 'Esc' to go back."))
+		return
+	}
+	end := min(m.offset+groupsPageSize, len(m.groups))
+	for i := m.offset; i < end; i++ {
+		group := m.groups[i]
+		cursor := "  "
+		if i == m.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		box := "[ ] " + group
+		if m.selected[group] {
+			box = selectedStyle.Render("[x] " + group)
+		}
+		view.WriteString(cursor + box + "\n")
+	}
+	if len(m.groups) > groupsPageSize {
+		view.WriteString(helpStyle.Render(fmt.Sprintf("\n%d-%d of %d groups", m.offset+1, end, len(m.groups))) + "\n")
+	}
+	selected := len(m.selectedGroups())
+	if selected == 0 {
+		view.WriteString("\nNo group selected: all projects you can access will be synced.\n")
+	} else {
+		view.WriteString(fmt.Sprintf("\n%d group(s) selected.\n", selected))
+	}
+	view.WriteString(helpStyle.Render("\nPress 'Space' to select, 'a' to select all or none, 'Enter' to continue, 'Esc' to go back."))
+}
+
+func validateProfile(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if strings.ContainsAny(s, " /\\:") {
+		return fmt.Errorf("profile name cannot contain spaces, slashes or colons")
+	}
+	return nil
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("enter the address of your GitLab instance, e.g. https://gitlab.example.com")
+	}
+	return nil
+}
+
+func validateToken(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("token cannot be empty")
+	}
+	return nil
+}
+
+func validateWorkingDir(s string) error {
+	dir, err := expandHome(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("should be full path: %s", s)
+	}
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	return nil
+}
+
+func validateBranch(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return fmt.Errorf("branch cannot be empty")
+	}
+	return nil
+}
+
+// expandHome replaces a leading "~" with the home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
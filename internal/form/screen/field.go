@@ -0,0 +1,462 @@
+// File: forms/screen/field.go
+package screen
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FieldType is the kind of input of a form field.
+type FieldType int
+
+const (
+	FieldText        FieldType = iota // single-line free text
+	FieldTextArea                     // multi-line free text; Enter inserts a line
+	FieldCheckbox                     // yes/no toggle
+	FieldSelect                       // one of Options
+	FieldMultiSelect                  // any of Options, which may be loaded asynchronously
+	FieldPath                         // file system path with Tab completion
+)
+
+// maxVisibleOptions is the number of options of a multi-select shown at once.
+const maxVisibleOptions = 6
+
+var (
+	optionStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("228"))
+	selectedStyle = lipgloss.NewStyle().Foreground(hotPink).Bold(true)
+)
+
+// Value is the typed value of a field. Text is set for text, text area, path and select fields,
+// Checked for checkboxes and Selected for multi-selects.
+type Value struct {
+	Type     FieldType
+	Text     string
+	Checked  bool
+	Selected []string
+}
+
+// String returns the value as it is passed on in context maps: checkboxes are YES or NO and the
+// options of a multi-select are joined with commas.
+func (v Value) String() string {
+	switch v.Type {
+	case FieldCheckbox:
+		if v.Checked {
+			return constant.ContextValueYES
+		}
+		return constant.ContextValueNO
+	case FieldMultiSelect:
+		return strings.Join(v.Selected, ",")
+	default:
+		return v.Text
+	}
+}
+
+// Values are the values of a form by field label.
+type Values map[string]Value
+
+// Strings returns the values in their string form, as stored in presets and context maps.
+func (v Values) Strings() map[string]string {
+	values := make(map[string]string, len(v))
+	for label, value := range v {
+		values[label] = value.String()
+	}
+	return values
+}
+
+// Text returns the text of a field, trimmed of surrounding white space.
+func (v Values) Text(label string) string {
+	return strings.TrimSpace(v[label].Text)
+}
+
+// Bool reports whether a checkbox is checked.
+func (v Values) Bool(label string) bool {
+	return v[label].Checked
+}
+
+// List returns the selected options of a multi-select.
+func (v Values) List(label string) []string {
+	return v[label].Selected
+}
+
+// OptionsMsg carries the options loaded for a multi-select field. The TUI must deliver it to the
+// form even if another screen is shown by then.
+type OptionsMsg struct {
+	form    *Model
+	label   string
+	options []string
+	err     error
+}
+
+// InputField represents a single input field with a label and the model of its type. Input is used
+// by text and path fields, and by multi-selects to add options that were not loaded.
+type InputField struct {
+	Label string          // Custom label for the input field
+	Type  FieldType       // Kind of input
+	Input textinput.Model // The actual input field model
+	Area  textarea.Model  // The text area of FieldTextArea
+
+	Checked  bool            // FieldCheckbox
+	Text     string          // Text shown next to a checkbox
+	Options  []string        // FieldSelect and FieldMultiSelect
+	Cursor   int             // Highlighted option; for FieldSelect the selected one
+	Selected map[string]bool // FieldMultiSelect
+
+	validate    func(string) error
+	loadOptions func() ([]string, error)
+	loading     bool
+	loadErr     error
+}
+
+// newField creates the field described by button.
+func newField(button ButtonModel) InputField {
+	field := InputField{
+		Label:       button.Label,
+		Type:        button.Type,
+		Options:     slices.Clone(button.Options),
+		Selected:    make(map[string]bool),
+		Text:        button.PlaceHolder,
+		validate:    button.Validate,
+		loadOptions: button.LoadOptions,
+	}
+
+	field.Input = textinput.New()
+	field.Input.Placeholder = button.PlaceHolder
+	field.Input.Width = button.Width
+	field.Input.PromptStyle = labelStyle
+	field.Input.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("228")) // Light yellow for input text
+	if button.Validate != nil && (button.Type == FieldText || button.Type == FieldPath) {
+		field.Input.Validate = button.Validate
+	}
+	switch button.Type {
+	case FieldPath:
+		field.Input.ShowSuggestions = true
+		field.Input.KeyMap.NextSuggestion.SetKeys("ctrl+n")
+		field.Input.KeyMap.PrevSuggestion.SetKeys("ctrl+p")
+	case FieldMultiSelect:
+		field.Input.Placeholder = "type to add an option, Enter to add"
+	case FieldTextArea:
+		field.Area = textarea.New()
+		field.Area.Placeholder = button.PlaceHolder
+		field.Area.ShowLineNumbers = false
+		field.Area.SetWidth(button.Width)
+		field.Area.SetHeight(max(button.Height, 3))
+		field.Area.Blur()
+	}
+	return field
+}
+
+// Value returns the field's value in its string form.
+func (f *InputField) Value() string {
+	return f.TypedValue().String()
+}
+
+// TypedValue returns the field's value.
+func (f *InputField) TypedValue() Value {
+	value := Value{Type: f.Type}
+	switch f.Type {
+	case FieldTextArea:
+		value.Text = f.Area.Value()
+	case FieldCheckbox:
+		value.Checked = f.Checked
+	case FieldSelect:
+		if f.Cursor < len(f.Options) {
+			value.Text = f.Options[f.Cursor]
+		}
+	case FieldMultiSelect:
+		value.Selected = []string{}
+		for _, option := range f.Options {
+			if f.Selected[option] {
+				value.Selected = append(value.Selected, option)
+			}
+		}
+	default:
+		value.Text = f.Input.Value()
+	}
+	return value
+}
+
+// SetValue sets the field from its string form, as returned by Value. Options of a select or
+// multi-select that are not in the list yet are added.
+func (f *InputField) SetValue(s string) {
+	switch f.Type {
+	case FieldTextArea:
+		f.Area.SetValue(s)
+	case FieldCheckbox:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "yes", "true", "y", "1", "x":
+			f.Checked = true
+		default:
+			f.Checked = false
+		}
+	case FieldSelect:
+		f.Cursor = f.addOption(s)
+	case FieldMultiSelect:
+		f.Selected = make(map[string]bool)
+		for _, option := range strings.Split(s, ",") {
+			if option = strings.TrimSpace(option); option != "" {
+				f.addOption(option)
+				f.Selected[option] = true
+			}
+		}
+	default:
+		f.Input.SetValue(s)
+		f.Input.CursorEnd()
+	}
+}
+
+// addOption adds option to the list unless it is there already and returns its index.
+func (f *InputField) addOption(option string) int {
+	if i := slices.Index(f.Options, option); i >= 0 {
+		return i
+	}
+	f.Options = append(f.Options, option)
+	return len(f.Options) - 1
+}
+
+// Validate runs the field's validation on its string form.
+func (f *InputField) Validate() error {
+	if f.validate == nil {
+		return nil
+	}
+	return f.validate(f.Value())
+}
+
+// setFocus focuses or blurs the model of the field.
+func (f *InputField) setFocus(focused bool) {
+	if f.Type == FieldTextArea {
+		if focused {
+			f.Area.Focus()
+		} else {
+			f.Area.Blur()
+		}
+		return
+	}
+	if focused {
+		f.Input.Focus()
+	} else {
+		f.Input.Blur()
+	}
+}
+
+// load returns the command that loads the options of a multi-select, if it has a source.
+func (f *InputField) load(form *Model) tea.Cmd {
+	if f.loadOptions == nil || f.loading {
+		return nil
+	}
+	f.loading, f.loadErr = true, nil
+	loadOptions, label := f.loadOptions, f.Label
+	return func() tea.Msg {
+		options, err := loadOptions()
+		return OptionsMsg{form: form, label: label, options: options, err: err}
+	}
+}
+
+// setOptions merges loaded options into the list; selected options are kept.
+func (f *InputField) setOptions(msg OptionsMsg) {
+	f.loading, f.loadErr = false, msg.err
+	options := slices.Clone(msg.options)
+	for _, option := range f.Options {
+		if f.Selected[option] && !slices.Contains(options, option) {
+			options = append(options, option)
+		}
+	}
+	f.Options = options
+	f.Cursor = min(f.Cursor, max(len(f.Options)-1, 0))
+}
+
+// handleKey gives a focused field the keys it uses itself and reports whether the key was used.
+// Tab, Enter and the arrows otherwise move between fields.
+func (f *InputField) handleKey(msg tea.KeyMsg) bool {
+	switch f.Type {
+	case FieldTextArea:
+		switch msg.String() {
+		case "enter", "up", "down":
+			f.Area, _ = f.Area.Update(msg)
+			return true
+		}
+	case FieldCheckbox:
+		switch msg.String() {
+		case " ", "x":
+			f.Checked = !f.Checked
+			return true
+		}
+	case FieldSelect:
+		if len(f.Options) == 0 {
+			return false
+		}
+		switch msg.String() {
+		case "right", " ":
+			f.Cursor = (f.Cursor + 1) % len(f.Options)
+			return true
+		case "left":
+			f.Cursor = (f.Cursor + len(f.Options) - 1) % len(f.Options)
+			return true
+		}
+	case FieldMultiSelect:
+		switch msg.String() {
+		case "up":
+			f.Cursor = max(f.Cursor-1, 0)
+			return true
+		case "down":
+			f.Cursor = min(f.Cursor+1, max(len(f.Options)-1, 0))
+			return true
+		case " ":
+			if f.Cursor < len(f.Options) {
+				option := f.Options[f.Cursor]
+				f.Selected[option] = !f.Selected[option]
+			}
+			return true
+		case "ctrl+a":
+			all := len(f.Options) > 0 && len(f.TypedValue().Selected) == len(f.Options)
+			for _, option := range f.Options {
+				f.Selected[option] = !all
+			}
+			return true
+		case "enter":
+			option := strings.TrimSpace(f.Input.Value())
+			if option == "" {
+				return false
+			}
+			f.Cursor = f.addOption(option)
+			f.Selected[option] = true
+			f.Input.SetValue("")
+			return true
+		}
+	case FieldPath:
+		// Tab completes the path while there is a completion; otherwise it moves to the next field.
+		if msg.String() == "tab" {
+			if suggestion := f.Input.CurrentSuggestion(); suggestion != "" && suggestion != f.Input.Value() {
+				f.Input, _ = f.Input.Update(msg)
+				f.suggestPaths()
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// update passes other messages, such as typed characters, to the model of the field.
+func (f *InputField) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	switch f.Type {
+	case FieldTextArea:
+		f.Area, cmd = f.Area.Update(msg)
+	case FieldCheckbox, FieldSelect:
+	default:
+		before := f.Input.Value()
+		f.Input, cmd = f.Input.Update(msg)
+		if f.Type == FieldPath && f.Input.Value() != before {
+			f.suggestPaths()
+		}
+	}
+	return cmd
+}
+
+// suggestPaths offers the entries of the directory being typed as completions; directories end
+// with a slash so completion can go on into them.
+func (f *InputField) suggestPaths() {
+	value := f.Input.Value()
+	dir, prefix := filepath.Split(value)
+	if strings.HasPrefix(value, "~") {
+		return
+	}
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		f.Input.SetSuggestions(nil)
+		return
+	}
+	var suggestions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		suggestions = append(suggestions, dir+name)
+	}
+	f.Input.SetSuggestions(suggestions)
+}
+
+// view renders the input of the field.
+func (f *InputField) view(focused bool) string {
+	switch f.Type {
+	case FieldTextArea:
+		return f.Area.View()
+	case FieldCheckbox:
+		box := "[ ] "
+		if f.Checked {
+			box = "[x] "
+		}
+		return cursor(focused) + optionStyle.Render(box+f.Text)
+	case FieldSelect:
+		var options []string
+		for i, option := range f.Options {
+			if option == "" {
+				option = "default"
+			}
+			if i == f.Cursor {
+				options = append(options, selectedStyle.Render("(•) "+option))
+			} else {
+				options = append(options, optionStyle.Render("( ) "+option))
+			}
+		}
+		return cursor(focused) + strings.Join(options, "  ")
+	case FieldMultiSelect:
+		return f.multiSelectView(focused)
+	default:
+		return f.Input.View()
+	}
+}
+
+// multiSelectView renders the options around the cursor, and the input to add options when focused.
+func (f *InputField) multiSelectView(focused bool) string {
+	var view strings.Builder
+	start := min(max(f.Cursor-maxVisibleOptions/2, 0), max(len(f.Options)-maxVisibleOptions, 0))
+	end := min(start+maxVisibleOptions, len(f.Options))
+	if start > 0 {
+		view.WriteString(continueStyle.Render(fmt.Sprintf("  ↑ %d more", start)) + "\n")
+	}
+	for i := start; i < end; i++ {
+		box := "[ ] "
+		style := optionStyle
+		if f.Selected[f.Options[i]] {
+			box, style = "[x] ", selectedStyle
+		}
+		view.WriteString(cursor(focused && i == f.Cursor) + style.Render(box+f.Options[i]) + "\n")
+	}
+	if end < len(f.Options) {
+		view.WriteString(continueStyle.Render(fmt.Sprintf("  ↓ %d more", len(f.Options)-end)) + "\n")
+	}
+	switch {
+	case f.loading:
+		view.WriteString(continueStyle.Render("  loading options...") + "\n")
+	case f.loadErr != nil:
+		view.WriteString(errorStyle.Render(fmt.Sprintf("  cannot load options: %v", f.loadErr)) + "\n")
+	case len(f.Options) == 0:
+		view.WriteString(continueStyle.Render("  no options") + "\n")
+	}
+	if focused {
+		view.WriteString(f.Input.View() + "\n")
+		view.WriteString(continueStyle.Render("  Space toggles, Ctrl+A toggles all, Ctrl+R reloads"))
+	}
+	return strings.TrimSuffix(view.String(), "\n")
+}
+
+// cursor marks the focused line of checkboxes and option lists.
+func cursor(focused bool) string {
+	if focused {
+		return labelStyle.Render("> ")
+	}
+	return "  "
+}
@@ -27,12 +27,6 @@ var (
 	labelStyle    = lipgloss.NewStyle().Bold(true)
 )
 
-// InputField represents a single input field with a label and the actual textinput.Model
-type InputField struct {
-	Label string          // Custom label for the input field
-	Input textinput.Model // The actual input field model
-}
-
 // Model represents the state of the form/screen
 type Model struct {
 	Inputs    []InputField      // A slice to store multiple input fields with labels
@@ -42,12 +36,17 @@ type Model struct {
 	Submitted bool              // Field to track form submission
 }
 
-// ButtonModel represents the configuration for an input field
+// ButtonModel represents the configuration for an input field. Fields are single-line text unless
+// Type says otherwise.
 type ButtonModel struct {
 	Label       string
-	PlaceHolder string
+	PlaceHolder string // Placeholder of text fields, text next to a checkbox
 	Width       int
-	Validate    func(string) error
+	Validate    func(string) error // Called with the value in its string form
+	Type        FieldType
+	Height      int                      // Lines of a text area
+	Options     []string                 // Options of a select or multi-select
+	LoadOptions func() ([]string, error) // Loads the options of a multi-select when the form starts
 }
 
 // NewModel initializes and returns a new form model with given input configurations
@@ -59,39 +58,24 @@ func NewModel(buttons []ButtonModel, logWriter *logWriter.Logger) *Model {
 	}
 
 	for _, button := range buttons {
-		model.addInput(button.Label, button.PlaceHolder, button.Width, button.Validate)
+		model.Inputs = append(model.Inputs, newField(button))
 	}
 
 	// Focus the first input if available
 	if len(model.Inputs) > 0 {
-		model.Inputs[0].Input.Focus()
+		model.Inputs[0].setFocus(true)
 	}
 
 	return model
 }
 
-// addInput is a helper function to create new input fields programmatically with custom labels
-func (m *Model) addInput(label string, placeholder string, width int, validateFunc func(string) error) {
-	newInput := textinput.New()
-	newInput.Placeholder = placeholder
-	newInput.Width = width
-	newInput.PromptStyle = labelStyle
-	newInput.TextStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("228")) // Light yellow for input text
-
-	if validateFunc != nil {
-		newInput.Validate = validateFunc
-	}
-
-	// Append new input to the model's input slice with the custom label
-	m.Inputs = append(m.Inputs, InputField{
-		Label: label,
-		Input: newInput,
-	})
-}
-
-// Init is the initialization command of the program
+// Init is the initialization command of the program; it also starts loading the options of multi-selects.
 func (m *Model) Init() tea.Cmd {
-	return textinput.Blink
+	cmds := []tea.Cmd{textinput.Blink}
+	for i := range m.Inputs {
+		cmds = append(cmds, m.Inputs[i].load(m))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles all key-based interactions and updates the state accordingly
@@ -100,32 +84,24 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// The focused field uses some keys itself, e.g. Enter in a text area or Space in a checkbox.
+		if m.Focused < len(m.Inputs) && !m.Submitted && m.Inputs[m.Focused].handleKey(msg) {
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "enter":
 			// Attempt to submit the form if the last input is focused
 			if m.Focused == len(m.Inputs)-1 {
-				// Validate all inputs before submission
-				for i, inputField := range m.Inputs {
-					if inputField.Input.Validate != nil {
-						err := inputField.Input.Validate(inputField.Input.Value())
-						if err != nil {
-							m.Err = fmt.Errorf("error in '%s': %v", inputField.Label, err)
-							m.Focused = i // Focus the input with the error
-							return m, nil
-						}
-					}
-				}
-
-				// If validation passes, collect the values and log them
-				values := m.GetValue()
-				m.logWriter.GreenString("Collected values: %v", values)
-				m.Submitted = true
-				return m, nil
+				return m.submit()
 			}
 
 			// Move to the next input
 			m.nextInput()
 
+		case "alt+enter":
+			return m.submit()
+
 		case "ctrl+c", "esc":
 			if msg.String() == "esc" {
 				return m, func() tea.Msg { return message.BackMsg{} }
@@ -137,33 +113,73 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "shift+tab", "up":
 			m.prevInput()
+
+		case "ctrl+r":
+			if m.Focused < len(m.Inputs) {
+				return m, m.Inputs[m.Focused].load(m)
+			}
+
+		default:
+			if m.Focused < len(m.Inputs) && !m.Submitted {
+				cmds = append(cmds, m.Inputs[m.Focused].update(msg))
+			}
 		}
 
+	case OptionsMsg:
+		if msg.form != m {
+			return m, nil
+		}
+		for i := range m.Inputs {
+			if m.Inputs[i].Label == msg.label {
+				m.Inputs[i].setOptions(msg)
+			}
+		}
+		if msg.err != nil {
+			m.logWriter.ErrorString("Error loading the options of '%s': %v", msg.label, msg.err)
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		// Adjust input widths based on terminal size if necessary
 		for i := range m.Inputs {
 			labelLen := len(m.Inputs[i].Label)
 			m.Inputs[i].Input.Width = msg.Width - labelLen - 6 // Adjust as needed
+			if m.Inputs[i].Type == FieldTextArea {
+				m.Inputs[i].Area.SetWidth(msg.Width - labelLen - 6)
+			}
+		}
+
+	default:
+		// Other messages, such as cursor blinks, go to every field.
+		for i := range m.Inputs {
+			cmds = append(cmds, m.Inputs[i].update(msg))
 		}
 	}
 
 	// Handle focus: ensure only the focused input is active
 	for i := range m.Inputs {
-		if i == m.Focused && !m.Submitted {
-			m.Inputs[i].Input.Focus()
-		} else {
-			m.Inputs[i].Input.Blur()
-		}
+		m.Inputs[i].setFocus(i == m.Focused && !m.Submitted)
 	}
 
-	// Update each input field and collect commands
+	return m, tea.Batch(cmds...)
+}
+
+// submit validates every field and marks the form as submitted.
+func (m *Model) submit() (tea.Model, tea.Cmd) {
+	// Validate all inputs before submission
 	for i := range m.Inputs {
-		var cmd tea.Cmd
-		m.Inputs[i].Input, cmd = m.Inputs[i].Input.Update(msg)
-		cmds = append(cmds, cmd)
+		if err := m.Inputs[i].Validate(); err != nil {
+			m.Err = fmt.Errorf("error in '%s': %v", m.Inputs[i].Label, err)
+			m.Focused = i // Focus the input with the error
+			return m, nil
+		}
 	}
 
-	return m, tea.Batch(cmds...)
+	// If validation passes, collect the values and log them
+	values := m.GetValue()
+	m.logWriter.GreenString("Collected values: %v", values.Strings())
+	m.Submitted = true
+	return m, nil
 }
 
 // View renders the UI layout
@@ -175,14 +191,19 @@ func (m *Model) View() string {
 
 	// Render each input field with its label
 	var view strings.Builder
-	for _, inputField := range m.Inputs {
-		view.WriteString(fmt.Sprintf("%s\n%s\n\n", inputStyle.Render(inputField.Label), inputField.Input.View()))
+	for i := range m.Inputs {
+		view.WriteString(fmt.Sprintf("%s\n%s\n\n", inputStyle.Render(m.Inputs[i].Label), m.Inputs[i].view(i == m.Focused && !m.Submitted)))
 	}
 
 	if m.Submitted {
 		view.WriteString(continueStyle.Render("\nForm submitted successfully! Press 'Esc' to go back or 'Ctrl+C' to quit."))
 	} else {
-		view.WriteString(continueStyle.Render("Press 'Enter' to submit, 'Tab' to navigate, 'Esc' to go back, 'Ctrl+C' to quit."))
+		// Enter starts a new line in a text area, so a form ending with one is only submitted with Alt+Enter.
+		submitHelp := "Press 'Enter' on the last field or 'Alt+Enter' to submit"
+		if len(m.Inputs) > 0 && m.Inputs[len(m.Inputs)-1].Type == FieldTextArea {
+			submitHelp = "Press 'Alt+Enter' to submit"
+		}
+		view.WriteString(continueStyle.Render(submitHelp + ", 'Tab' to navigate, 'Esc' to go back, 'Ctrl+C' to quit."))
 	}
 
 	view.WriteString(errorMessage)
@@ -209,14 +230,25 @@ func (m *Model) prevInput() {
 	}
 }
 
-// GetValue collects the values entered in the input fields and returns them as a map
-func (m *Model) GetValue() map[string]string {
-	values := make(map[string]string) // Initialize the map
+// GetValue collects the typed values of the input fields by label
+func (m *Model) GetValue() Values {
+	values := make(Values) // Initialize the map
 
 	// Loop through each input field in the model
-	for _, inputField := range m.Inputs {
-		values[inputField.Label] = inputField.Input.Value() // Add label and value to the map
+	for i := range m.Inputs {
+		values[m.Inputs[i].Label] = m.Inputs[i].TypedValue() // Add label and value to the map
 	}
 
 	return values
 }
+
+// SetValues fills the input fields with the values by label, in the string form returned by
+// Values.Strings. Fields without a value are cleared, and the first field gets the focus.
+func (m *Model) SetValues(values map[string]string) {
+	for i := range m.Inputs {
+		m.Inputs[i].SetValue(values[m.Inputs[i].Label])
+	}
+	m.Focused = 0
+	m.Submitted = false
+	m.Err = nil
+}
@@ -0,0 +1,252 @@
+// File: forms/settingsScreen/settingsScreen.go
+package settingsScreen
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/form/screen"
+	"github.com/sinaw369/Hermes/internal/logWriter"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FieldProfile is the label of the profile field; the other fields are labelled with their keys.
+const FieldProfile = "PROFILE"
+
+// maxValueWidth truncates long values in the settings table.
+const maxValueWidth = 40
+
+var (
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF06B7"))
+	keyStyle    = lipgloss.NewStyle().Bold(true)
+	sourceStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+	changeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+)
+
+// Model shows the effective configuration and where each value came from, and edits the keys
+// that can change while Hermes runs. cfg is shared with the rest of the UI, so changes apply right away.
+type Model struct {
+	cfg       *config.Config
+	form      *screen.Model
+	logWriter *logWriter.Logger
+	status    string
+	err       error
+}
+
+// NewModel returns the settings screen for cfg.
+func NewModel(cfg *config.Config, logger *logWriter.Logger) *Model {
+	m := &Model{cfg: cfg, logWriter: logger}
+	m.resetForm()
+	return m
+}
+
+// resetForm fills the form with the current values of cfg.
+func (m *Model) resetForm() {
+	fields := []screen.ButtonModel{{
+		Label:       FieldProfile,
+		PlaceHolder: "profile of " + m.cfg.File,
+		Width:       50,
+		Validate:    m.validateProfile,
+	}}
+	for _, key := range config.EditableKeys {
+		fields = append(fields, screen.ButtonModel{
+			Label:       key,
+			PlaceHolder: placeholders[key],
+			Width:       50,
+			Validate:    func(s string) error { return m.cfg.ValidateSetting(key, s) },
+		})
+	}
+	m.form = screen.NewModel(fields, m.logWriter)
+
+	values := m.settingValues()
+	m.form.Inputs[0].Input.SetValue(m.cfg.Profile)
+	for i, key := range config.EditableKeys {
+		m.form.Inputs[i+1].Input.SetValue(values[key])
+	}
+}
+
+var placeholders = map[string]string{
+	config.KeyWorkingDir:     "/home/username/gitlab",
+	config.KeyDiffBranchFrom: "production",
+	config.KeyDiffBranchTo:   "develop",
+	config.KeyConcurrency:    "0 (each command's default)",
+	config.KeyCloneProtocol:  "ssh or https",
+	config.KeyInclude:        "Include patterns (comma-separated)",
+	config.KeyExclude:        "Exclude patterns (comma-separated)",
+}
+
+// settingValues returns the current values by key.
+func (m *Model) settingValues() map[string]string {
+	values := make(map[string]string)
+	for _, setting := range m.cfg.Settings() {
+		values[setting.Key] = setting.Value
+	}
+	return values
+}
+
+// validateProfile accepts the current profile or one defined in the config file.
+func (m *Model) validateProfile(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" || s == m.cfg.Profile {
+		return nil
+	}
+	names, err := config.ProfileNames(m.cfg.File)
+	if err != nil {
+		return fmt.Errorf("cannot read profiles: %v", err)
+	}
+	if !slices.Contains(names, s) {
+		return fmt.Errorf("profile %q not found in %s (available: %s)", s, m.cfg.File, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// Init is the initialization command of the screen.
+func (m *Model) Init() tea.Cmd {
+	return m.form.Init()
+}
+
+// Update applies the form on Enter and also saves it to the config file on Ctrl+S.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+s":
+			m.submit(true)
+			return m, nil
+		case "ctrl+r":
+			m.resetForm()
+			m.status, m.err = "Form reset to the current settings.", nil
+			return m, nil
+		}
+	}
+
+	updated, cmd := m.form.Update(msg)
+	m.form = updated.(*screen.Model)
+	if m.form.Submitted {
+		m.submit(false)
+	}
+	return m, cmd
+}
+
+// submit switches the profile if it was changed, otherwise applies the edited values for the rest
+// of the run and, if save is set, writes them to the active profile.
+func (m *Model) submit(save bool) {
+	m.form.Submitted = false
+	m.status, m.err = "", nil
+	for i := range m.form.Inputs {
+		if err := m.form.Inputs[i].Validate(); err != nil {
+			m.err = fmt.Errorf("%s: %v", m.form.Inputs[i].Label, err)
+			return
+		}
+	}
+	values := m.form.GetValue().Strings()
+
+	if profile := strings.TrimSpace(values[FieldProfile]); profile != "" && profile != m.cfg.Profile {
+		m.switchProfile(profile)
+		return
+	}
+
+	changed, err := m.cfg.Apply(values)
+	if err != nil {
+		m.err = err
+		return
+	}
+	if !save {
+		if len(changed) == 0 {
+			m.status = "Nothing changed."
+		} else {
+			m.status = fmt.Sprintf("Applied %s until Hermes exits; press Ctrl+S to save.", strings.Join(changed, ", "))
+		}
+		m.logWriter.InfoString("Settings applied: %s", strings.Join(changed, ", "))
+		return
+	}
+
+	// Save every key changed in this session, including the ones applied earlier with Enter.
+	var unsaved []string
+	for _, setting := range m.cfg.Settings() {
+		if setting.Source == config.SourceUI {
+			unsaved = append(unsaved, setting.Key)
+		}
+	}
+	if len(unsaved) == 0 {
+		m.status = "Nothing to save."
+		return
+	}
+	profile, err := m.cfg.SaveSettings(unsaved)
+	if err != nil {
+		m.err = fmt.Errorf("saving settings: %v", err)
+		return
+	}
+	m.form.Inputs[0].Input.SetValue(profile)
+	m.status = fmt.Sprintf("Saved %s to profile %q of %s.", strings.Join(unsaved, ", "), profile, m.cfg.File)
+	var overridden []string
+	for _, key := range unsaved {
+		if os.Getenv(key) != "" {
+			overridden = append(overridden, key)
+		}
+	}
+	if len(overridden) > 0 {
+		m.status += fmt.Sprintf(" %s set in the environment will still override the profile on the next start.", strings.Join(overridden, ", "))
+	}
+	m.logWriter.InfoString("%s", m.status)
+}
+
+// switchProfile loads the configuration with another profile. Changes that were not saved are dropped.
+func (m *Model) switchProfile(profile string) {
+	loaded, err := config.Load(profile)
+	if err != nil {
+		m.err = fmt.Errorf("switching to profile %q: %v", profile, err)
+		return
+	}
+	*m.cfg = *loaded
+	m.resetForm()
+	m.status = fmt.Sprintf("Switched to profile %q.", profile)
+	m.logWriter.InfoString("%s", m.status)
+}
+
+// View renders the form next to the table of effective settings.
+func (m *Model) View() string {
+	var header strings.Builder
+	header.WriteString(titleStyle.Render("Settings"))
+	if m.cfg.Profile != "" {
+		header.WriteString(fmt.Sprintf("  profile %q of %s", m.cfg.Profile, m.cfg.File))
+	} else {
+		header.WriteString("  no profile in use; Ctrl+S creates profile \"default\" in " + m.cfg.File)
+	}
+	header.WriteString("\n\n")
+
+	var table strings.Builder
+	table.WriteString(titleStyle.Render("Effective configuration") + "\n")
+	for _, setting := range m.cfg.Settings() {
+		value := setting.Value
+		if value == "" {
+			value = sourceStyle.Render("(not set)")
+		} else if len(value) > maxValueWidth {
+			value = value[:maxValueWidth-3] + "..."
+		}
+		source := sourceStyle.Render(setting.Source)
+		if setting.Source == config.SourceUI {
+			source = changeStyle.Render(setting.Source)
+		}
+		table.WriteString(fmt.Sprintf("%s = %s  %s\n", keyStyle.Render(setting.Key), value, source))
+	}
+
+	var footer strings.Builder
+	footer.WriteString(helpStyle.Render("\nEnter on the last field applies the changes until Hermes exits, Ctrl+S also saves them to the profile,\n" +
+		"Ctrl+R resets the form. Change PROFILE and press Enter to switch profiles."))
+	if m.status != "" {
+		footer.WriteString("\n" + statusStyle.Render(m.status))
+	}
+	if m.err != nil {
+		footer.WriteString("\n" + errorStyle.Render(m.err.Error()))
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top, m.form.View(), "    ", table.String())
+	return header.String() + body + "\n" + footer.String()
+}
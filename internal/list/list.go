@@ -3,6 +3,7 @@ package listview
 
 import (
 	"fmt"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,6 +12,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 )
 
 // -------------------------------------------------------------------
@@ -24,6 +26,7 @@ var (
 	helpStyle       = list.DefaultStyles().HelpStyle.PaddingLeft(4).PaddingBottom(1)
 	dirStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("33"))
 	fileStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("15"))
+	markStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true)
 )
 
 // -------------------------------------------------------------------
@@ -69,8 +72,10 @@ type FileItem struct {
 // FilterValue enables filtering.
 func (f FileItem) FilterValue() string { return f.Name }
 
-// fileDelegate renders FileItem objects.
-type fileDelegate struct{}
+// fileDelegate renders FileItem objects; repositories in selected are marked.
+type fileDelegate struct {
+	selected map[string]bool
+}
 
 func (d fileDelegate) Height() int                               { return 1 }
 func (d fileDelegate) Spacing() int                              { return 0 }
@@ -86,6 +91,9 @@ func (d fileDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		icon = "📁" // folder icon
 	}
 	line := fmt.Sprintf("%s %s", icon, item.Name)
+	if d.selected[item.Path] {
+		line = markStyle.Render("✓ ") + line
+	}
 	if index == m.Index() {
 		fmt.Fprint(w, selectedStyle.Render("> "+line))
 	} else {
@@ -138,6 +146,26 @@ type Config struct {
 	FilteringEnabled bool
 }
 
+// batchKeys are the keys of the actions on the selected repositories, in directory mode.
+var batchKeys = map[string]string{
+	"P": message.BatchPull,
+	"M": message.BatchMerge,
+	"D": message.BatchDiff,
+	"X": message.BatchCommand,
+}
+
+// selectionHelp describes the selection and batch keys of directory mode.
+func selectionHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select repo")),
+		key.NewBinding(key.WithKeys("*"), key.WithHelp("*", "select shown repos")),
+		key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "pull selected")),
+		key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "merge selected")),
+		key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "diff report")),
+		key.NewBinding(key.WithKeys("X"), key.WithHelp("X", "run command")),
+	}
+}
+
 // Model can operate in either static or directory mode.
 type Model struct {
 	List        list.Model
@@ -146,6 +174,7 @@ type Model struct {
 	Choice      string
 	logWriter   *logWriter.Logger
 	InitialPath string
+	selected    map[string]bool // selected repositories by path, kept across directories
 }
 
 // NewModel creates a new Model based on the provided config.
@@ -177,8 +206,11 @@ func NewModel(config Config, logger *logWriter.Logger) (*Model, error) {
 			config.InitialPath = newPath
 		}
 
-		l := list.New(items, fileDelegate{}, config.Width, config.Height)
+		selected := make(map[string]bool)
+		l := list.New(items, fileDelegate{selected: selected}, config.Width, config.Height)
 		l.Title = title
+		l.AdditionalShortHelpKeys = selectionHelp
+		l.AdditionalFullHelpKeys = selectionHelp
 		l.SetShowStatusBar(config.ShowStatusBar)
 		l.SetFilteringEnabled(config.FilteringEnabled)
 		l.Styles.Title = titleStyle
@@ -192,6 +224,7 @@ func NewModel(config Config, logger *logWriter.Logger) (*Model, error) {
 			Choice:      "",
 			logWriter:   logger,
 			InitialPath: config.InitialPath,
+			selected:    selected,
 		}, nil
 	}
 
@@ -238,7 +271,78 @@ func (m *Model) SetPath(newPath string) error {
 	}
 	m.CurrentPath = newPath
 	m.List.SetItems(items)
+	m.updateTitle()
+	return nil
+}
+
+// updateTitle shows the current directory and the number of selected repositories.
+func (m *Model) updateTitle() {
 	m.List.Title = fmt.Sprintf("Directory: %s", m.CurrentPath)
+	if len(m.selected) > 0 {
+		m.List.Title += fmt.Sprintf(" (%d repos selected)", len(m.selected))
+	}
+}
+
+// Selected returns the paths of the selected repositories, sorted.
+func (m *Model) Selected() []string {
+	paths := make([]string, 0, len(m.selected))
+	for path := range m.selected {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// ClearSelection unselects all repositories.
+func (m *Model) ClearSelection() {
+	clear(m.selected)
+	m.updateTitle()
+}
+
+// toggleSelection selects the repository under the cursor, or unselects it.
+func (m *Model) toggleSelection() tea.Cmd {
+	item, ok := m.List.SelectedItem().(FileItem)
+	if !ok {
+		return nil
+	}
+	if isRepo, _ := isGitRepo(item.Path); !isRepo {
+		return m.List.NewStatusMessage("only Git repositories can be selected")
+	}
+	if m.selected[item.Path] {
+		delete(m.selected, item.Path)
+	} else {
+		m.selected[item.Path] = true
+	}
+	m.updateTitle()
+	return nil
+}
+
+// selectVisible selects the repositories shown, which are the ones matching the filter if one is
+// applied. If all of them are selected already, they are unselected instead.
+func (m *Model) selectVisible() tea.Cmd {
+	var repos []string
+	for _, listItem := range m.List.VisibleItems() {
+		if item, ok := listItem.(FileItem); ok {
+			if isRepo, _ := isGitRepo(item.Path); isRepo {
+				repos = append(repos, item.Path)
+			}
+		}
+	}
+	if len(repos) == 0 {
+		return m.List.NewStatusMessage("no Git repositories shown")
+	}
+	allSelected := true
+	for _, path := range repos {
+		allSelected = allSelected && m.selected[path]
+	}
+	for _, path := range repos {
+		if allSelected {
+			delete(m.selected, path)
+		} else {
+			m.selected[path] = true
+		}
+	}
+	m.updateTitle()
 	return nil
 }
 
@@ -266,6 +370,22 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, func() tea.Msg { return message.BackMsg{} }
 		}
 
+		// Selection keys are typed into the filter while it is being edited.
+		if m.isDir && m.List.FilterState() != list.Filtering {
+			switch keyStr := msg.String(); keyStr {
+			case " ":
+				return m, m.toggleSelection()
+			case "*":
+				return m, m.selectVisible()
+			case "P", "M", "D", "X":
+				if len(m.selected) == 0 {
+					return m, m.List.NewStatusMessage("select repositories with space or * first")
+				}
+				batch := message.BatchMsg{Action: batchKeys[keyStr], Paths: m.Selected()}
+				return m, func() tea.Msg { return batch }
+			}
+		}
+
 		// If we're in directory mode, handle navigation keys.
 		if m.isDir {
 			switch msg.String() {
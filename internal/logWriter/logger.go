@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/fatih/color"
+	"github.com/sinaw369/Hermes/internal/redact"
 )
 
 // Logger struct wraps a standard Go logger with color capabilities
@@ -31,6 +32,16 @@ func NewLogger(writer io.Writer, LstdFlags, disabled bool) *Logger {
 	}
 }
 
+// redacted formats the message and masks registered secrets, such as the GitLab token.
+func (l *Logger) redacted(format string, a ...interface{}) string {
+	return redact.String(fmt.Sprintf(format, a...))
+}
+
+// println writes the redacted message in the color of colorize.
+func (l *Logger) println(colorize func(format string, a ...interface{}) string, format string, a ...interface{}) {
+	l.writer.Println(colorize("%s", l.redacted(format, a...)))
+}
+
 // Error method makes Logger implement the error interface
 func (l *Logger) Error() string {
 	return "Logger: error occurred"
@@ -39,7 +50,7 @@ func (l *Logger) Error() string {
 // InfoString logs a formatted message in white color
 func (l *Logger) InfoString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiWhiteString(format, a...))
+		l.println(color.HiWhiteString, format, a...)
 	}
 }
 
@@ -47,7 +58,7 @@ func (l *Logger) InfoString(format string, a ...interface{}) {
 func (l *Logger) ErrorString(format string, a ...interface{}) {
 	if !l.disabled {
 		l.RedOnWhiteString("⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ERROR ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓ ⇓")
-		l.writer.Println(color.HiRedString(format, a...))
+		l.println(color.HiRedString, format, a...)
 		l.RedOnWhiteString("⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ERROR ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑ ⇑")
 	}
 }
@@ -55,42 +66,42 @@ func (l *Logger) ErrorString(format string, a ...interface{}) {
 // GreenString logs a formatted message in green color
 func (l *Logger) GreenString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiGreenString(format, a...))
+		l.println(color.HiGreenString, format, a...)
 	}
 }
 
 // BlackString logs a formatted message in black color
 func (l *Logger) BlackString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiBlackString(format, a...))
+		l.println(color.HiBlackString, format, a...)
 	}
 }
 
 // BlueString logs a formatted message in blue color
 func (l *Logger) BlueString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiBlueString(format, a...))
+		l.println(color.HiBlueString, format, a...)
 	}
 }
 
 // RedString logs a formatted message in red color
 func (l *Logger) RedString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiRedString(format, a...))
+		l.println(color.HiRedString, format, a...)
 	}
 }
 
 // MagentaString logs a formatted message in magenta color
 func (l *Logger) MagentaString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiMagentaString(format, a...))
+		l.println(color.HiMagentaString, format, a...)
 	}
 }
 
 // YellowString logs a formatted message in yellow color
 func (l *Logger) YellowString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(color.HiYellowString(format, a...))
+		l.println(color.HiYellowString, format, a...)
 	}
 }
 
@@ -103,13 +114,13 @@ var (
 // BlackOnWhiteString logs a formatted message in black text on a white background
 func (l *Logger) BlackOnWhiteString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(blackOnWhite(fmt.Sprintf(format, a...)))
+		l.writer.Println(blackOnWhite(l.redacted(format, a...)))
 	}
 }
 
 // RedOnWhiteString logs a formatted message in red text on a white background
 func (l *Logger) RedOnWhiteString(format string, a ...interface{}) {
 	if !l.disabled {
-		l.writer.Println(redOnWhite(fmt.Sprintf(format, a...)))
+		l.writer.Println(redOnWhite(l.redacted(format, a...)))
 	}
 }
@@ -11,3 +11,17 @@ type GitRepoMsg struct {
 
 // BackToFolderMsg signals that the user wants to leave the Git repo view.
 type BackToFolderMsg struct{}
+
+// Batch actions on the repositories selected in the file list.
+const (
+	BatchPull    = "pull"
+	BatchMerge   = "merge"
+	BatchDiff    = "diff"
+	BatchCommand = "command"
+)
+
+// BatchMsg signals that a batch action was chosen for the selected repositories.
+type BatchMsg struct {
+	Action string
+	Paths  []string
+}
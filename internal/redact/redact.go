@@ -0,0 +1,44 @@
+// Package redact removes registered secrets, such as the GitLab token, from text before it is logged.
+package redact
+
+import (
+	"strings"
+	"sync"
+)
+
+// mask replaces every registered secret.
+const mask = "[REDACTED]"
+
+var (
+	mu       sync.RWMutex
+	secrets  []string
+	replacer = strings.NewReplacer()
+)
+
+// Add registers a secret. Empty and very short values are ignored, as masking them would
+// garble unrelated text.
+func Add(secret string) {
+	if len(secret) < 4 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, s := range secrets {
+		pairs = append(pairs, s, mask)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// String returns s with every registered secret masked.
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return replacer.Replace(s)
+}
@@ -0,0 +1,241 @@
+// Package releasenotes groups commits by their Conventional Commit type and renders them as Markdown.
+package releasenotes
+
+import (
+	"fmt"
+	"github.com/sinaw369/Hermes/internal/client"
+	"regexp"
+	"strings"
+)
+
+// Group is a section of the release notes.
+type Group int
+
+// Sections of the release notes, in the order they are rendered.
+const (
+	Breaking Group = iota
+	Features
+	Fixes
+	Chores
+	Other
+)
+
+var groupTitles = map[Group]string{
+	Breaking: "Breaking changes",
+	Features: "Features",
+	Fixes:    "Fixes",
+	Chores:   "Chores",
+	Other:    "Other changes",
+}
+
+// groupsByType maps Conventional Commit types to sections; unknown types and other subjects go to Other.
+var groupsByType = map[string]Group{
+	"feat":     Features,
+	"fix":      Fixes,
+	"chore":    Chores,
+	"build":    Chores,
+	"ci":       Chores,
+	"docs":     Chores,
+	"perf":     Chores,
+	"refactor": Chores,
+	"revert":   Chores,
+	"style":    Chores,
+	"test":     Chores,
+}
+
+var (
+	// conventionalSubject matches "type(scope)!: description".
+	conventionalSubject = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+	// breakingFooter matches the footer that marks a breaking change in the commit body.
+	breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+	// reference matches merge request (!123) and issue (#45) references, optionally of another project.
+	reference = regexp.MustCompile(`(^|[\s(\[])((?:[\w.-]+/)+[\w.-]+)?([!#])(\d+)\b`)
+)
+
+// Entry is a commit in the release notes.
+type Entry struct {
+	Group       Group
+	Scope       string
+	Description string
+	Hash        string
+	// References are the merge requests and issues the commit refers to outside its subject: in
+	// its body, or the merge request it was merged with.
+	References []string
+}
+
+// Notes are the release notes of one repository between two refs.
+type Notes struct {
+	Repository string // name shown in the notes
+	BaseURL    string // web address of GitLab; references are not linked when empty
+	Project    string // path of the project on GitLab, e.g. "backend/api"
+	From       string
+	To         string
+	Entries    []Entry
+}
+
+// Parse classifies a commit by its Conventional Commit subject. Commits marked with "!" or a
+// BREAKING CHANGE footer are breaking changes, whatever their type.
+func Parse(commit client.Commit) Entry {
+	entry := Entry{Group: Other, Description: commit.Subject, Hash: commit.Hash}
+	entry.References = references(commit)
+	match := conventionalSubject.FindStringSubmatch(commit.Subject)
+	if match == nil {
+		return entry
+	}
+	if group, ok := groupsByType[strings.ToLower(match[1])]; ok {
+		entry.Group = group
+	}
+	entry.Scope = match[2]
+	entry.Description = match[4]
+	if match[3] == "!" || breakingFooter.MatchString(commit.Body) {
+		entry.Group = Breaking
+	}
+	return entry
+}
+
+// references returns the references of the commit body and its merge request, in that order and
+// without duplicates.
+func references(commit client.Commit) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	add := func(ref string) {
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, match := range reference.FindAllStringSubmatch(commit.Body, -1) {
+		add(match[2] + match[3] + match[4])
+	}
+	add(commit.MergeRequest)
+	return refs
+}
+
+// New returns the release notes of the commits, which are listed newest first.
+func New(repository, baseURL, project, from, to string, commits []client.Commit) Notes {
+	notes := Notes{
+		Repository: repository,
+		BaseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v4"),
+		Project:    project,
+		From:       from,
+		To:         to,
+	}
+	for _, commit := range commits {
+		notes.Entries = append(notes.Entries, Parse(commit))
+	}
+	return notes
+}
+
+// Markdown renders the notes of the repository with a section per group.
+func (n Notes) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s..%s)\n\n", n.Repository, n.From, n.To)
+	if len(n.Entries) == 0 {
+		b.WriteString("No changes.\n")
+		return b.String()
+	}
+	for group := Breaking; group <= Other; group++ {
+		var lines []string
+		for _, entry := range n.Entries {
+			if entry.Group == group {
+				lines = append(lines, "- "+n.line(entry))
+			}
+		}
+		writeSection(&b, group, lines)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Combined renders the notes of several repositories together: one section per group, with the
+// repository named on every line. Repositories without changes are left out.
+func Combined(from, to string, notes []Notes) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Release notes (%s..%s)\n\n", from, to)
+	empty := true
+	for group := Breaking; group <= Other; group++ {
+		var lines []string
+		for _, n := range notes {
+			for _, entry := range n.Entries {
+				if entry.Group == group {
+					lines = append(lines, fmt.Sprintf("- `%s` %s", n.Repository, n.line(entry)))
+				}
+			}
+		}
+		if len(lines) > 0 {
+			empty = false
+		}
+		writeSection(&b, group, lines)
+	}
+	if empty {
+		b.WriteString("No changes.\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeSection writes a section of the notes, if it has lines.
+func writeSection(b *strings.Builder, group Group, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "### %s\n\n%s\n\n", groupTitles[group], strings.Join(lines, "\n"))
+}
+
+// line renders an entry: its scope, its description with linked references, then its other
+// references and its commit.
+func (n Notes) line(entry Entry) string {
+	line := n.linkReferences(entry.Description)
+	if entry.Scope != "" {
+		line = fmt.Sprintf("**%s:** %s", entry.Scope, line)
+	}
+	seen := make(map[string]bool)
+	for _, match := range reference.FindAllStringSubmatch(entry.Description, -1) {
+		seen[n.shorten(match[2]+match[3]+match[4])] = true
+	}
+	var links []string
+	for _, ref := range entry.References {
+		if ref = n.shorten(ref); !seen[ref] {
+			seen[ref] = true
+			links = append(links, strings.TrimSpace(n.linkReferences(ref)))
+		}
+	}
+	hash := entry.Hash[:min(len(entry.Hash), 8)]
+	if url := n.url(n.Project, "commit", entry.Hash); url != "" {
+		hash = fmt.Sprintf("[%s](%s)", hash, url)
+	}
+	return fmt.Sprintf("%s (%s)", line, strings.Join(append(links, hash), ", "))
+}
+
+// shorten drops the project from references to the project itself, as GitLab does.
+func (n Notes) shorten(ref string) string {
+	if i := strings.LastIndexAny(ref, "!#"); i > 0 && ref[:i] == n.Project {
+		return ref[i:]
+	}
+	return ref
+}
+
+// linkReferences turns the merge request and issue references of the text into links.
+func (n Notes) linkReferences(text string) string {
+	return reference.ReplaceAllStringFunc(text, func(ref string) string {
+		match := reference.FindStringSubmatch(ref)
+		project, kind := match[2], "issues"
+		if project == "" {
+			project = n.Project
+		}
+		if match[3] == "!" {
+			kind = "merge_requests"
+		}
+		url := n.url(project, kind, match[4])
+		if url == "" {
+			return ref
+		}
+		return fmt.Sprintf("%s[%s%s%s](%s)", match[1], match[2], match[3], match[4], url)
+	})
+}
+
+// url returns the web address of a page of the project, or "" if it is not known.
+func (n Notes) url(project, kind, id string) string {
+	if n.BaseURL == "" || project == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/-/%s/%s", n.BaseURL, project, kind, id)
+}
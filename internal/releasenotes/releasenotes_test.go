@@ -0,0 +1,185 @@
+package releasenotes
+
+import (
+	"github.com/sinaw369/Hermes/internal/client"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		commit client.Commit
+		want   Entry
+	}{
+		{
+			name:   "feature with scope",
+			commit: client.Commit{Hash: "a1", Subject: "feat(api): add search"},
+			want:   Entry{Group: Features, Scope: "api", Description: "add search", Hash: "a1"},
+		},
+		{
+			name:   "fix without scope",
+			commit: client.Commit{Hash: "a1", Subject: "fix: handle empty query"},
+			want:   Entry{Group: Fixes, Description: "handle empty query", Hash: "a1"},
+		},
+		{
+			name:   "type is case insensitive",
+			commit: client.Commit{Hash: "a1", Subject: "Docs: explain presets"},
+			want:   Entry{Group: Chores, Description: "explain presets", Hash: "a1"},
+		},
+		{
+			name:   "breaking marker",
+			commit: client.Commit{Hash: "a1", Subject: "refactor(config)!: rename WORKING_DIR"},
+			want:   Entry{Group: Breaking, Scope: "config", Description: "rename WORKING_DIR", Hash: "a1"},
+		},
+		{
+			name:   "breaking footer",
+			commit: client.Commit{Hash: "a1", Subject: "feat: drop v3 API", Body: "BREAKING CHANGE: v3 clients stop working"},
+			want:   Entry{Group: Breaking, Description: "drop v3 API", Hash: "a1"},
+		},
+		{
+			name:   "unknown type",
+			commit: client.Commit{Hash: "a1", Subject: "wip: something"},
+			want:   Entry{Group: Other, Description: "something", Hash: "a1"},
+		},
+		{
+			name:   "not a conventional subject",
+			commit: client.Commit{Hash: "a1", Subject: "Update README"},
+			want:   Entry{Group: Other, Description: "Update README", Hash: "a1"},
+		},
+		{
+			name: "body and merge request references",
+			commit: client.Commit{
+				Hash:         "a1",
+				Subject:      "fix: handle empty query",
+				Body:         "Closes #45 and other/project#7.\nSee also #45.",
+				MergeRequest: "grp/proj!12",
+			},
+			want: Entry{Group: Fixes, Description: "handle empty query", Hash: "a1", References: []string{"#45", "other/project#7", "grp/proj!12"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.commit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLine(t *testing.T) {
+	linked := Notes{BaseURL: "https://gitlab.example.com", Project: "grp/proj"}
+	tests := []struct {
+		name  string
+		notes Notes
+		entry Entry
+		want  string
+	}{
+		{
+			name:  "without base URL",
+			entry: Entry{Description: "add search (!12)", Hash: "0123456789abcdef"},
+			want:  "add search (!12) (01234567)",
+		},
+		{
+			name:  "scope and commit link",
+			notes: linked,
+			entry: Entry{Scope: "api", Description: "add search", Hash: "0123456789abcdef"},
+			want:  "**api:** add search ([01234567](https://gitlab.example.com/grp/proj/-/commit/0123456789abcdef))",
+		},
+		{
+			name:  "references in the description",
+			notes: linked,
+			entry: Entry{Description: "fix #45 and other/lib!3", Hash: "abc"},
+			want: "fix [#45](https://gitlab.example.com/grp/proj/-/issues/45) and " +
+				"[other/lib!3](https://gitlab.example.com/other/lib/-/merge_requests/3) " +
+				"([abc](https://gitlab.example.com/grp/proj/-/commit/abc))",
+		},
+		{
+			name:  "references of the project are shortened and not repeated",
+			notes: linked,
+			entry: Entry{Description: "handle empty query (!12)", Hash: "abc", References: []string{"#45", "grp/proj!12", "grp/proj#45"}},
+			want: "handle empty query ([!12](https://gitlab.example.com/grp/proj/-/merge_requests/12)) " +
+				"([#45](https://gitlab.example.com/grp/proj/-/issues/45), [abc](https://gitlab.example.com/grp/proj/-/commit/abc))",
+		},
+		{
+			name:  "references of other projects keep their project",
+			notes: Notes{Project: "grp/proj"},
+			entry: Entry{Description: "bump", Hash: "abc", References: []string{"other/lib!3"}},
+			want:  "bump (other/lib!3, abc)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.notes.line(tt.entry); got != tt.want {
+				t.Errorf("line() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	commits := []client.Commit{
+		{Hash: "c3", Subject: "chore: tidy"},
+		{Hash: "c2", Subject: "feat!: new config"},
+		{Hash: "c1", Subject: "feat(api): add search"},
+	}
+	tests := []struct {
+		name  string
+		notes Notes
+		want  string
+	}{
+		{
+			name:  "grouped",
+			notes: New("proj", "", "", "v1", "v2", commits),
+			want: "## proj (v1..v2)\n\n" +
+				"### Breaking changes\n\n- new config (c2)\n\n" +
+				"### Features\n\n- **api:** add search (c1)\n\n" +
+				"### Chores\n\n- tidy (c3)\n",
+		},
+		{
+			name:  "no changes",
+			notes: New("proj", "", "", "v1", "v2", nil),
+			want:  "## proj (v1..v2)\n\nNo changes.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.notes.Markdown(); got != tt.want {
+				t.Errorf("Markdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombined(t *testing.T) {
+	notes := []Notes{
+		New("api", "", "", "v1", "v2", []client.Commit{{Hash: "a1", Subject: "fix: timeout"}}),
+		New("web", "", "", "v1", "v2", nil),
+		New("worker", "", "", "v1", "v2", []client.Commit{{Hash: "b1", Subject: "fix: retry"}, {Hash: "b2", Subject: "feat: queue"}}),
+	}
+	tests := []struct {
+		name  string
+		notes []Notes
+		want  string
+	}{
+		{
+			name:  "repositories named on every line",
+			notes: notes,
+			want: "## Release notes (v1..v2)\n\n" +
+				"### Features\n\n- `worker` queue (b2)\n\n" +
+				"### Fixes\n\n- `api` timeout (a1)\n- `worker` retry (b1)\n",
+		},
+		{
+			name:  "no changes",
+			notes: notes[1:2],
+			want:  "## Release notes (v1..v2)\n\nNo changes.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Combined("v1", "v2", tt.notes); got != tt.want {
+				t.Errorf("Combined() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
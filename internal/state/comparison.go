@@ -0,0 +1,59 @@
+package state
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// comparisonsFile keeps the last comparison made in the diff screen, by repository path.
+const comparisonsFile = "diff/comparisons.json"
+
+// Comparison is a pair of refs compared in the diff screen: remote branch names or tags.
+type Comparison struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Saved time.Time `json:"saved"`
+}
+
+// Comparisons returns the last comparison of every repository, by repository path.
+func (s *Store) Comparisons() (map[string]Comparison, error) {
+	comparisons := make(map[string]Comparison)
+	if err := s.Load(comparisonsFile, &comparisons); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return comparisons, nil
+}
+
+// Comparison returns the last comparison made for the repository, if any.
+func (s *Store) Comparison(repoPath string) (Comparison, bool, error) {
+	comparisons, err := s.Comparisons()
+	if err != nil {
+		return Comparison{}, false, err
+	}
+	comparison, ok := comparisons[repoPath]
+	return comparison, ok, nil
+}
+
+// SaveComparison remembers the comparison made for the repository.
+func (s *Store) SaveComparison(repoPath, from, to string) error {
+	comparisons, err := s.Comparisons()
+	if err != nil {
+		return err
+	}
+	comparisons[repoPath] = Comparison{From: from, To: to, Saved: time.Now()}
+	return s.Save(comparisonsFile, comparisons)
+}
+
+// ForgetComparison removes the remembered comparison of the repository.
+func (s *Store) ForgetComparison(repoPath string) error {
+	comparisons, err := s.Comparisons()
+	if err != nil {
+		return err
+	}
+	if _, ok := comparisons[repoPath]; !ok {
+		return nil
+	}
+	delete(comparisons, repoPath)
+	return s.Save(comparisonsFile, comparisons)
+}
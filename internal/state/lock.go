@@ -0,0 +1,171 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LockInfo identifies the process holding a lock. It is stored as JSON in the lock file.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Operation string    `json:"operation"`
+	Started   time.Time `json:"started"`
+}
+
+// Stale reports whether the holder of the lock is known to be gone: it ran on this host and its
+// process no longer exists. Locks taken on other hosts are never considered stale.
+func (i LockInfo) Stale() bool {
+	return i.Host == hostname() && !ProcessAlive(i.PID)
+}
+
+// String describes the holder, e.g. "sync (pid 4242 on build-01, since 2006-01-02 15:04:05)".
+func (i LockInfo) String() string {
+	return fmt.Sprintf("%s (pid %d on %s, since %s)", i.Operation, i.PID, i.Host, i.Started.Format(time.DateTime))
+}
+
+// LockedError is returned by TryLock when another live process holds the lock.
+type LockedError struct {
+	Path   string
+	Holder LockInfo
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s is locked by %s", e.Path, e.Holder)
+}
+
+// Lock is a lock file held by this process.
+type Lock struct {
+	path string
+	info LockInfo
+}
+
+// TryLock creates the lock file at path for the operation. If the file exists and its holder is
+// still alive, a *LockedError is returned; a stale lock left behind by a crashed process on this
+// host is replaced.
+func TryLock(path, operation string) (*Lock, error) {
+	info := LockInfo{PID: os.Getpid(), Host: hostname(), Operation: operation, Started: time.Now()}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		err := createExclusive(path, data)
+		if err == nil {
+			return &Lock{path: path, info: info}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("creating lock %s: %w", path, err)
+		}
+
+		holder, err := ReadLock(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // released in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !holder.Stale() {
+			return nil, &LockedError{Path: path, Holder: holder}
+		}
+		if _, err := RemoveLock(path, holder); err != nil {
+			return nil, fmt.Errorf("removing stale lock %s: %w", path, err)
+		}
+	}
+	return nil, fmt.Errorf("could not create lock %s", path)
+}
+
+// RemoveLock removes the lock file at path if it is still held by holder, and reports whether it
+// did. The file is first renamed to a name of its own, so of several processes removing the same
+// stale lock only one gets it; a lock that another process took in the meantime is put back.
+func RemoveLock(path string, holder LockInfo) (bool, error) {
+	tombstone, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.stale")
+	if err != nil {
+		return false, err
+	}
+	tombstone.Close()
+	defer os.Remove(tombstone.Name())
+	if err := os.Rename(path, tombstone.Name()); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil // removed by another process
+		}
+		return false, err
+	}
+	moved, err := ReadLock(tombstone.Name())
+	if err == nil && !moved.sameHolder(holder) {
+		if err := os.Link(tombstone.Name(), path); err != nil && !errors.Is(err, fs.ErrExist) {
+			return false, fmt.Errorf("restoring lock %s: %w", path, err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// sameHolder reports whether both describe the same holder of a lock.
+func (i LockInfo) sameHolder(other LockInfo) bool {
+	return i.PID == other.PID && i.Host == other.Host && i.Started.Equal(other.Started)
+}
+
+// createExclusive writes data to a temporary file and links it to path, so the lock file
+// never exists without its content. It fails with fs.ErrExist if path already exists.
+func createExclusive(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), path)
+}
+
+// ReadLock returns the holder recorded in the lock file at path.
+func ReadLock(path string) (LockInfo, error) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("reading lock %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// Release removes the lock file, unless it was removed or taken over by another process in the meantime.
+func (l *Lock) Release() error {
+	holder, err := ReadLock(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !holder.sameHolder(l.info) {
+		return nil
+	}
+	return os.Remove(l.path)
+}
+
+// hostname returns the name of this host, or "unknown" if it cannot be determined.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return name
+}
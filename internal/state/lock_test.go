@@ -0,0 +1,111 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deadPID returns the PID of a process that has exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot start a process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+// writeLock writes a lock file held by holder.
+func writeLock(t *testing.T, path string, holder LockInfo) {
+	t.Helper()
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTryLockReplacesStaleLock(t *testing.T) {
+	stale := LockInfo{PID: deadPID(t), Host: hostname(), Operation: "test", Started: time.Now().Add(-time.Hour)}
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(t.TempDir(), "workspace.lock")
+		writeLock(t, path, stale)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var acquired, locked int
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := TryLock(path, "test")
+				var lockedErr *LockedError
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					acquired++
+				case errors.As(err, &lockedErr):
+					locked++
+				default:
+					t.Errorf("TryLock: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if acquired != 1 || locked != 7 {
+			t.Fatalf("run %d: %d processes acquired the lock and %d found it locked, want 1 and 7", i, acquired, locked)
+		}
+		if holder, err := ReadLock(path); err != nil || holder.PID != os.Getpid() {
+			t.Fatalf("run %d: lock held by %+v, %v, want this process", i, holder, err)
+		}
+		if leftovers, _ := filepath.Glob(path + ".*"); len(leftovers) > 0 {
+			t.Fatalf("run %d: files left behind: %v", i, leftovers)
+		}
+	}
+}
+
+func TestRemoveLock(t *testing.T) {
+	stale := LockInfo{PID: deadPID(t), Host: hostname(), Operation: "test", Started: time.Now().Add(-time.Hour)}
+	fresh := LockInfo{PID: os.Getpid(), Host: hostname(), Operation: "test", Started: time.Now()}
+	tests := []struct {
+		name        string
+		onDisk      *LockInfo
+		wantRemoved bool
+		wantHolder  *LockInfo
+	}{
+		{name: "still the stale holder", onDisk: &stale, wantRemoved: true},
+		{name: "taken over in the meantime", onDisk: &fresh, wantHolder: &fresh},
+		{name: "already removed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "workspace.lock")
+			if tt.onDisk != nil {
+				writeLock(t, path, *tt.onDisk)
+			}
+			removed, err := RemoveLock(path, stale)
+			if err != nil || removed != tt.wantRemoved {
+				t.Fatalf("RemoveLock() = %v, %v, want %v", removed, err, tt.wantRemoved)
+			}
+			holder, err := ReadLock(path)
+			switch {
+			case tt.wantHolder == nil && !errors.Is(err, os.ErrNotExist):
+				t.Errorf("lock file after RemoveLock: %+v, %v, want none", holder, err)
+			case tt.wantHolder != nil && (err != nil || !holder.sameHolder(*tt.wantHolder)):
+				t.Errorf("lock file after RemoveLock: %+v, %v, want %+v", holder, err, *tt.wantHolder)
+			}
+			if leftovers, _ := filepath.Glob(path + ".*"); len(leftovers) > 0 {
+				t.Errorf("files left behind: %v", leftovers)
+			}
+		})
+	}
+}
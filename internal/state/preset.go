@@ -0,0 +1,125 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Forms whose values can be kept as presets and in the history.
+const (
+	FormPull  = "pull"
+	FormMerge = "merge"
+)
+
+// FormValues are the values of a form, by field label, saved as a preset or a history entry.
+type FormValues struct {
+	Name   string            `json:"name,omitempty"`
+	Values map[string]string `json:"values"`
+	Saved  time.Time         `json:"saved"`
+}
+
+func presetFile(form, name string) string {
+	return filepath.Join("presets", form, name+".json")
+}
+
+func historyFile(form string) string {
+	return filepath.Join("history", form+".json")
+}
+
+// ValidPresetName reports an error if name cannot be used as the file name of a preset.
+func ValidPresetName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) {
+		return fmt.Errorf("invalid preset name %q: it must not be empty or contain slashes or colons", name)
+	}
+	return nil
+}
+
+// SavePreset stores the values of a form under name, replacing a preset with the same name.
+func (s *Store) SavePreset(form, name string, values map[string]string) error {
+	if err := ValidPresetName(name); err != nil {
+		return err
+	}
+	return s.Save(presetFile(form, name), FormValues{Name: name, Values: values, Saved: time.Now()})
+}
+
+// LoadPreset returns the named preset of a form.
+func (s *Store) LoadPreset(form, name string) (*FormValues, error) {
+	if err := ValidPresetName(name); err != nil {
+		return nil, err
+	}
+	var preset FormValues
+	if err := s.Load(presetFile(form, name), &preset); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no %s preset named %q in %s", form, name, s.Path(filepath.Dir(presetFile(form, name))))
+		}
+		return nil, err
+	}
+	preset.Name = name
+	return &preset, nil
+}
+
+// DeletePreset removes the named preset of a form.
+func (s *Store) DeletePreset(form, name string) error {
+	if err := ValidPresetName(name); err != nil {
+		return err
+	}
+	return os.Remove(s.Path(presetFile(form, name)))
+}
+
+// Presets returns the presets of a form sorted by name. Unreadable preset files are skipped.
+func (s *Store) Presets(form string) ([]FormValues, error) {
+	entries, err := os.ReadDir(s.Path(filepath.Join("presets", form)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var presets []FormValues
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if preset, err := s.LoadPreset(form, name); err == nil {
+			presets = append(presets, *preset)
+		}
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// AddHistory records a submission of a form and keeps the limit most recent ones. An earlier
+// submission with the same values is replaced, so repeated runs do not fill the history.
+func (s *Store) AddHistory(form string, values map[string]string, limit int) error {
+	history, err := s.History(form)
+	if err != nil {
+		return err
+	}
+	entries := []FormValues{{Values: values, Saved: time.Now()}}
+	for _, entry := range history {
+		if !maps.Equal(entry.Values, values) {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return s.Save(historyFile(form), entries)
+}
+
+// History returns the recorded submissions of a form, newest first.
+func (s *Store) History(form string) ([]FormValues, error) {
+	var history []FormValues
+	if err := s.Load(historyFile(form), &history); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,136 @@
+package state
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestValidPresetName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "nightly"},
+		{name: "release 1.2"},
+		{name: "", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "a/b", wantErr: true},
+		{name: `a\b`, wantErr: true},
+		{name: "c:", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidPresetName(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("ValidPresetName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for name, values := range map[string]map[string]string{
+		"nightly": {"Branch": "develop"},
+		"release": {"Branch": "main"},
+	} {
+		if err := store.SavePreset(FormPull, name, values); err != nil {
+			t.Fatalf("SavePreset(%q): %v", name, err)
+		}
+	}
+	if err := store.SavePreset(FormMerge, "other", map[string]string{"Target": "main"}); err != nil {
+		t.Fatalf("SavePreset: %v", err)
+	}
+	if err := store.SavePreset(FormPull, "nightly", map[string]string{"Branch": "next"}); err != nil {
+		t.Fatalf("SavePreset: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		form       string
+		preset     string
+		wantValues map[string]string
+		wantErr    bool
+	}{
+		{name: "saved", form: FormPull, preset: "release", wantValues: map[string]string{"Branch": "main"}},
+		{name: "replaced", form: FormPull, preset: "nightly", wantValues: map[string]string{"Branch": "next"}},
+		{name: "other form", form: FormMerge, preset: "other", wantValues: map[string]string{"Target": "main"}},
+		{name: "of another form", form: FormMerge, preset: "release", wantErr: true},
+		{name: "missing", form: FormPull, preset: "weekly", wantErr: true},
+		{name: "invalid name", form: FormPull, preset: "../release", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preset, err := store.LoadPreset(tt.form, tt.preset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadPreset(%q, %q) error = %v, wantErr %v", tt.form, tt.preset, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if preset.Name != tt.preset || !reflect.DeepEqual(preset.Values, tt.wantValues) {
+				t.Errorf("LoadPreset(%q, %q) = %q %v, want %q %v", tt.form, tt.preset, preset.Name, preset.Values, tt.preset, tt.wantValues)
+			}
+		})
+	}
+
+	presets, err := store.Presets(FormPull)
+	if err != nil {
+		t.Fatalf("Presets: %v", err)
+	}
+	if len(presets) != 2 || presets[0].Name != "nightly" || presets[1].Name != "release" {
+		t.Errorf("Presets() = %+v, want nightly and release", presets)
+	}
+
+	if err := store.DeletePreset(FormPull, "nightly"); err != nil {
+		t.Fatalf("DeletePreset: %v", err)
+	}
+	if _, err := store.LoadPreset(FormPull, "nightly"); err == nil {
+		t.Error("LoadPreset after DeletePreset succeeded")
+	}
+	if err := store.DeletePreset(FormPull, "nightly"); !os.IsNotExist(err) {
+		t.Errorf("DeletePreset of a missing preset error = %v, want not exist", err)
+	}
+	if presets, err := store.Presets("none"); err != nil || presets != nil {
+		t.Errorf("Presets of a form without presets = %v, %v", presets, err)
+	}
+}
+
+func TestAddHistory(t *testing.T) {
+	a := map[string]string{"Branch": "a"}
+	b := map[string]string{"Branch": "b"}
+	c := map[string]string{"Branch": "c"}
+	tests := []struct {
+		name   string
+		added  []map[string]string
+		limit  int
+		wanted []map[string]string
+	}{
+		{name: "newest first", added: []map[string]string{a, b, c}, limit: 10, wanted: []map[string]string{c, b, a}},
+		{name: "repeated values move to the front", added: []map[string]string{a, b, a}, limit: 10, wanted: []map[string]string{a, b}},
+		{name: "limited", added: []map[string]string{a, b, c}, limit: 2, wanted: []map[string]string{c, b}},
+		{name: "empty", limit: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(t.TempDir())
+			for _, values := range tt.added {
+				if err := store.AddHistory(FormPull, values, tt.limit); err != nil {
+					t.Fatalf("AddHistory: %v", err)
+				}
+			}
+			history, err := store.History(FormPull)
+			if err != nil {
+				t.Fatalf("History: %v", err)
+			}
+			var got []map[string]string
+			for _, entry := range history {
+				got = append(got, entry.Values)
+			}
+			if !reflect.DeepEqual(got, tt.wanted) {
+				t.Errorf("History() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package state stores Hermes runtime state, such as run summaries, as JSON files in the state directory.
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Documents written by the sync command, relative to the state directory.
+const (
+	SyncStatusFile  = "sync/status.json"
+	SyncHistoryFile = "sync/history.jsonl"
+)
+
+// SyncHistoryLimit is the number of run summaries kept in SyncHistoryFile.
+const SyncHistoryLimit = 1000
+
+// Store reads and writes JSON documents below a state directory.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store rooted at dir. The directory is created on the first write.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the state directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Path returns the absolute path of a document in the store.
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// Save writes v as indented JSON to name, replacing the file atomically.
+func (s *Store) Save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return s.write(name, append(data, '\n'))
+}
+
+// write replaces the content of name atomically.
+func (s *Store) write(name string, data []byte) error {
+	path := s.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the JSON document name into v. The returned error wraps os.ErrNotExist if it does not exist.
+func (s *Store) Load(name string, v interface{}) error {
+	data, err := os.ReadFile(s.Path(name))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", name, err)
+	}
+	return nil
+}
+
+// Append adds v as a single JSON line to name and drops the oldest lines beyond the limit most
+// recent ones. A limit of 0 keeps every line.
+func (s *Store) Append(name string, v interface{}, limit int) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", name, err)
+	}
+	path := s.Path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || limit <= 0 {
+		return err
+	}
+	return s.trim(name, limit)
+}
+
+// trim keeps the last limit lines of name.
+func (s *Store) trim(name string, limit int) error {
+	data, err := os.ReadFile(s.Path(name))
+	if err != nil {
+		return err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= limit {
+		return nil
+	}
+	return s.write(name, bytes.Join(lines[len(lines)-limit:], nil))
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		name     string
+		appended int
+		limit    int
+		want     string
+	}{
+		{name: "below the limit", appended: 2, limit: 3, want: "0\n1\n"},
+		{name: "at the limit", appended: 3, limit: 3, want: "0\n1\n2\n"},
+		{name: "oldest dropped", appended: 5, limit: 3, want: "2\n3\n4\n"},
+		{name: "no limit", appended: 5, want: "0\n1\n2\n3\n4\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(t.TempDir())
+			for i := 0; i < tt.appended; i++ {
+				if err := store.Append(SyncHistoryFile, i, tt.limit); err != nil {
+					t.Fatalf("Append: %v", err)
+				}
+			}
+			data, err := os.ReadFile(store.Path(SyncHistoryFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data); got != tt.want {
+				t.Errorf("history = %q, want %q", got, tt.want)
+			}
+			if info, err := os.Stat(store.Path(SyncHistoryFile)); err != nil || info.Mode().Perm() != 0o600 {
+				t.Errorf("history mode = %v, %v, want 0600", info.Mode().Perm(), err)
+			}
+			entries, _ := os.ReadDir(store.Path("sync"))
+			for _, entry := range entries {
+				if strings.HasSuffix(entry.Name(), ".tmp") {
+					t.Errorf("temporary file %s left behind", entry.Name())
+				}
+			}
+		})
+	}
+}
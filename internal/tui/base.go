@@ -9,13 +9,17 @@ import (
 	"github.com/sinaw369/Hermes/internal/client"
 	"github.com/sinaw369/Hermes/internal/config"
 	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/form/dashboard"
 	"github.com/sinaw369/Hermes/internal/form/diffscreen"
 	"github.com/sinaw369/Hermes/internal/form/logsScreen"
 	"github.com/sinaw369/Hermes/internal/form/progressScreen"
 	"github.com/sinaw369/Hermes/internal/form/screen"
+	"github.com/sinaw369/Hermes/internal/form/settingsScreen"
 	HermesList "github.com/sinaw369/Hermes/internal/list"
 	"github.com/sinaw369/Hermes/internal/logWriter"
 	HermesMsg "github.com/sinaw369/Hermes/internal/message"
+	"github.com/sinaw369/Hermes/internal/state"
+	"strings"
 	"time"
 )
 
@@ -31,6 +35,11 @@ const (
 	ScreenAutoMergeReq
 	ScreenShowFile
 	ScreenShowDiff
+	ScreenSettings
+	ScreenPresets
+	ScreenSavePreset
+	ScreenDashboard
+	ScreenBatchCommand
 
 	ScreenQuit
 )
@@ -55,6 +64,13 @@ type Model struct {
 	height             int // Window height
 	cfg                *config.Config
 	diffScreen         *diffscreen.Model
+	diffReturn         Screen // screen the diff was opened from
+	dashboard          *dashboard.Model
+	settingsScreen     *settingsScreen.Model
+	presets            presetState
+	batchRepos         []string // repositories selected on the Files screen for a batch action
+	commandScreen      *screen.Model
+	store              *state.Store
 }
 
 // Init initializes the application; no initial command is needed.
@@ -71,6 +87,31 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case dashboard.UpdateMsg:
+		// Statuses keep arriving while a diff opened from the dashboard is shown.
+		if m.dashboard == nil {
+			return m, nil
+		}
+		_, cmd := m.dashboard.Update(msg)
+		return m, cmd
+
+	case diffscreen.FetchedMsg:
+		// A fetch started by the diff screen may end after the user left it.
+		if m.diffScreen == nil {
+			return m, nil
+		}
+		_, cmd := m.diffScreen.Update(msg)
+		return m, cmd
+
+	case screen.OptionsMsg:
+		// Options may arrive after the user left the form, so they always go to both forms.
+		m.pullScreen.Update(msg)
+		m.autoMergeReqScreen.Update(msg)
+		return m, nil
+
+	case diffReportMsg:
+		return m.showDiffReport(msg.report)
+
 	case HermesMsg.BackMsg:
 		m.LogWriter.InfoString("Received BackMsg. Handling back navigation.")
 		return m.handleBack()
@@ -100,6 +141,16 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateShowFileScreen(msg)
 		case ScreenShowDiff:
 			return m.updateShowDiffScreen(msg)
+		case ScreenSettings:
+			return m.updateSettingsScreen(msg)
+		case ScreenDashboard:
+			return m.updateDashboardScreen(msg)
+		case ScreenPresets:
+			return m.updatePresetListScreen(msg)
+		case ScreenSavePreset:
+			return m.updateSavePresetScreen(msg)
+		case ScreenBatchCommand:
+			return m.updateBatchCommandScreen(msg)
 		}
 	}
 
@@ -127,6 +178,10 @@ func (m *Model) updateCurrentScreenSize(msg tea.WindowSizeMsg) (tea.Model, tea.C
 			m.fileList.List.Styles.PaginationStyle = newPaginationStyle
 		}
 		return m, nil
+	case ScreenDashboard:
+		updatedDashboard, cmd := m.dashboard.Update(msg)
+		m.dashboard = updatedDashboard.(*dashboard.Model)
+		return m, cmd
 	default:
 		return m, nil
 	}
@@ -137,10 +192,20 @@ func (m *Model) handleBack() (tea.Model, tea.Cmd) {
 	switch m.currentScreen {
 	case ScreenList:
 		m.currentScreen = ScreenWelcome
-	case ScreenPull, ScreenLogs, ScreenProgress, ScreenAutoMergeReq, ScreenShowFile:
+	case ScreenPull, ScreenLogs, ScreenProgress, ScreenShowFile, ScreenSettings, ScreenDashboard:
 		m.currentScreen = ScreenList
+	case ScreenAutoMergeReq, ScreenBatchCommand:
+		// Batch actions return to the Files screen they were started from.
+		if m.batchRepos != nil {
+			m.currentScreen = ScreenShowFile
+		} else {
+			m.currentScreen = ScreenList
+		}
+		m.batchRepos = nil
 	case ScreenShowDiff:
-		m.currentScreen = ScreenShowFile
+		m.currentScreen = m.diffReturn
+	case ScreenPresets, ScreenSavePreset:
+		m.currentScreen = m.presets.returnTo
 	default:
 		m.currentScreen = ScreenWelcome
 	}
@@ -176,11 +241,30 @@ func (m *Model) updateListScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.optionList.Choice {
 		case constant.OptionListPullPr:
 			m.currentScreen = ScreenPull
+			m.optionList.Choice = ""
+			return m, m.pullScreen.Init()
 		case constant.OptionListAutoMergeReq:
+			m.batchRepos = nil
 			m.currentScreen = ScreenAutoMergeReq
+			m.optionList.Choice = ""
+			return m, m.autoMergeReqScreen.Init()
 		case constant.OptionListLogs:
 			m.LogWriter.YellowString("Switching to Logs Screen...")
 			m.currentScreen = ScreenLogs
+		case constant.OptionListSettings:
+			m.LogWriter.YellowString("Switching to Settings Screen...")
+			// The screen is rebuilt every time, so it shows the configuration as it is now.
+			m.settingsScreen = settingsScreen.NewModel(m.cfg, m.LogWriter)
+			m.currentScreen = ScreenSettings
+			m.optionList.Choice = ""
+			return m, m.settingsScreen.Init()
+		case constant.OptionListDashboard:
+			m.LogWriter.YellowString("Switching to Dashboard Screen...")
+			// Rebuilt every time, so it follows WORKING_DIR and CONCURRENCY changed on the settings screen.
+			m.dashboard = dashboard.NewModel(m.cfg.WorkingDir, m.cfg.Concurrency, m.store, m.width, m.height, m.LogWriter)
+			m.currentScreen = ScreenDashboard
+			m.optionList.Choice = ""
+			return m, m.dashboard.Init()
 		case constant.OptionListShowProject:
 			m.LogWriter.YellowString("Switching to Show project Screen...")
 			if m.fileList == nil {
@@ -224,6 +308,9 @@ func (m *Model) updateListScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // updatePullScreen handles updates specific to the Pull Screen.
 func (m *Model) updatePullScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if handled, cmd := m.handlePresetKeys(msg, state.FormPull, ScreenPull); handled {
+		return m, cmd
+	}
 	updatedPullScreen, cmd := m.pullScreen.Update(msg)
 	m.pullScreen = updatedPullScreen.(*screen.Model)
 
@@ -240,10 +327,21 @@ func (m *Model) updatePullScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 		defer cancel()
 
 		// Collect form values.
-		values := m.pullScreen.GetValue()
+		form := m.pullScreen.GetValue()
+		values := form.Strings()
+		m.recordHistory(state.FormPull, values)
+		values[constant.ContextValueSyncPolicy] = form.Text(constant.PullFieldSyncPolicy)
+		if form.Bool(constant.PullFieldOneBranch) {
+			values[constant.ContextValuePullDefault] = constant.ContextValueYES
+			values[constant.ContextValuePullBranch] = form.Text(constant.PullFieldBranch)
+		}
 
-		// Initialize the GitLab client with the context.
-		gClient, err := client.NewTUIGitClient(ctx, updatesChan, values, m.cfg, m.logsScreen)
+		// Initialize the GitLab client with the context; the token is resolved from its source first.
+		var gClient *client.GitlabClient
+		err := m.cfg.Require(config.KeyGitlabBaseURL, config.KeyGitlabToken)
+		if err == nil {
+			gClient, err = client.NewTUIGitClient(ctx, updatesChan, values, m.cfg, m.logsScreen)
+		}
 		if err != nil {
 			m.LogWriter.RedString("GitClient Initialization Failed: %v", err)
 			m.currentScreen = ScreenLogs
@@ -269,6 +367,9 @@ func (m *Model) updatePullScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // updateAutoMergeScreen handles updates specific to the Pull Screen.
 func (m *Model) updateAutoMergeScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if handled, cmd := m.handlePresetKeys(msg, state.FormMerge, ScreenAutoMergeReq); handled {
+		return m, cmd
+	}
 	updatedAutoMergeReqScreen, cmd := m.autoMergeReqScreen.Update(msg)
 	m.autoMergeReqScreen = updatedAutoMergeReqScreen.(*screen.Model)
 
@@ -285,10 +386,19 @@ func (m *Model) updateAutoMergeScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 		defer cancel()
 
 		// Collect form values.
-		values := m.autoMergeReqScreen.GetValue()
+		values := m.autoMergeReqScreen.GetValue().Strings()
+		m.recordHistory(state.FormMerge, values)
+		if m.batchRepos != nil && values[constant.ContextValueDir] == "" {
+			// The workspace of the Files screen is locked for a merge on selected repositories.
+			values[constant.ContextValueDir] = m.fileList.InitialPath
+		}
 
-		// Initialize the GitLab client with the context.
-		gClient, err := client.NewTUIGitClient(ctx, updatesChan, values, m.cfg, m.logsScreen)
+		// Initialize the GitLab client with the context; the token is resolved from its source first.
+		var gClient *client.GitlabClient
+		err := m.cfg.Require(config.KeyGitlabBaseURL, config.KeyGitlabToken)
+		if err == nil {
+			gClient, err = client.NewTUIGitClient(ctx, updatesChan, values, m.cfg, m.logsScreen)
+		}
 		if err != nil {
 			m.LogWriter.RedString("GitClient Initialization Failed: %v", err)
 			m.currentScreen = ScreenLogs
@@ -300,6 +410,11 @@ func (m *Model) updateAutoMergeScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, logCmd
 		}
 
+		if m.batchRepos != nil {
+			gClient.SetRepositories(m.batchRepos)
+			m.batchRepos = nil
+		}
+
 		m.LogWriter.YellowString("Pull Automation Starting...")
 		// Launch the GitLab client processing in a separate goroutine.
 		go gClient.InitMergeAutomationFromDir() // (Note: If desired, gClient can be enhanced to accept the context.)
@@ -341,20 +456,53 @@ func (m *Model) updateShowFileScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch gitMsg := msg.(type) {
 	case HermesMsg.GitRepoMsg:
-		// Now you can access the Path field:
-		repoPath := gitMsg.Path
+		return m, tea.Batch(cmd, m.showDiff(gitMsg.Path, ScreenShowFile))
+	case HermesMsg.BatchMsg:
+		return m.startBatch(gitMsg)
+	}
 
-		// If diffScreen is not yet initialized, create it.
-		// (Assuming you want to show diff between branches specified in your configuration)
-		if m.diffScreen == nil {
-			m.diffScreen = diffscreen.NewDiffModel(m.width, m.height, repoPath, m.cfg.DiffBranchFrom, m.cfg.DifBranchTO)
-		} else {
-			m.diffScreen.UpdateFetch(repoPath)
+	return m, cmd
+}
+
+// showDiff shows the diff of the repository; going back returns to the screen it was opened from.
+// The returned command fetches the compared refs first if DIFF_FETCH is set.
+func (m *Model) showDiff(repoPath string, from Screen) tea.Cmd {
+	// If diffScreen is not yet initialized, create it.
+	// (Assuming you want to show diff between branches specified in your configuration)
+	if m.diffScreen == nil {
+		m.diffScreen = diffscreen.NewDiffModel(m.width, m.height, repoPath, m.cfg.DiffBranchFrom, m.cfg.DifBranchTO, m.store)
+	} else {
+		// The branches may have been changed on the settings screen.
+		m.diffScreen.SetBranches(m.cfg.DiffBranchFrom, m.cfg.DifBranchTO)
+		m.diffScreen.UpdateFetch(repoPath)
+	}
+	m.diffScreen.SetFetcher(m.fetchDiffRefs, m.cfg.DiffFetch)
+	m.diffReturn = from
+	m.currentScreen = ScreenShowDiff
+	return m.diffScreen.AutoFetch()
+}
+
+// fetchDiffRefs fetches the refs compared in the diff screen from origin.
+func (m *Model) fetchDiffRefs(repoPath string, names ...string) error {
+	if m.cfg.CloneProtocol == constant.CloneProtocolHTTPS {
+		if err := m.cfg.Require(config.KeyGitlabToken); err != nil {
+			return err
 		}
-		m.currentScreen = ScreenShowDiff
-		return m, cmd
 	}
+	gClient, err := client.NewTUIGitClient(context.Background(), nil, nil, m.cfg, m.logsScreen)
+	if err != nil {
+		return err
+	}
+	return gClient.FetchRefs(repoPath, names...)
+}
 
+// updateDashboardScreen handles updates specific to the Dashboard Screen.
+func (m *Model) updateDashboardScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updatedDashboard, cmd := m.dashboard.Update(msg)
+	m.dashboard = updatedDashboard.(*dashboard.Model)
+	if gitMsg, ok := msg.(HermesMsg.GitRepoMsg); ok {
+		cmd = tea.Batch(cmd, m.showDiff(gitMsg.Path, ScreenDashboard))
+	}
 	return m, cmd
 }
 func (m *Model) updateShowDiffScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -362,15 +510,29 @@ func (m *Model) updateShowDiffScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.diffScreen = updatedDiff.(*diffscreen.Model)
 	switch msg.(type) {
 	case HermesMsg.BackToFolderMsg:
-
-		m.fileList.SetPath(m.fileList.CurrentPath)
-		m.currentScreen = ScreenShowFile
+		if m.diffReturn == ScreenShowFile {
+			m.fileList.SetPath(m.fileList.CurrentPath)
+		}
+		m.currentScreen = m.diffReturn
 		return m, cmd
 	}
 
 	return m, cmd
 }
 
+// updateSettingsScreen handles updates specific to the Settings Screen.
+func (m *Model) updateSettingsScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updatedSettingsScreen, cmd := m.settingsScreen.Update(msg)
+	m.settingsScreen = updatedSettingsScreen.(*settingsScreen.Model)
+	// Switching to another profile may change STATE_DIR; the dashboard, presets, history and the
+	// comparisons of the diff screen then use the state of the new profile.
+	if m.cfg.StateDir != m.store.Dir() {
+		m.store = state.NewStore(m.cfg.StateDir)
+		m.diffScreen = nil
+	}
+	return m, cmd
+}
+
 // View renders the UI based on the current screen.
 func (m *Model) View() string {
 	if m.quitting {
@@ -383,9 +545,9 @@ func (m *Model) View() string {
 	case ScreenList:
 		return m.optionList.View()
 	case ScreenPull:
-		return m.pullScreen.View()
+		return m.pullScreen.View() + presetHelp
 	case ScreenAutoMergeReq:
-		return m.autoMergeReqScreen.View()
+		return m.batchHeader() + m.autoMergeReqScreen.View() + presetHelp
 	case ScreenProgress:
 		return m.progressScreen.View()
 	case ScreenShowFile:
@@ -394,6 +556,16 @@ func (m *Model) View() string {
 		return m.logsScreen.View()
 	case ScreenShowDiff:
 		return m.diffScreen.View()
+	case ScreenSettings:
+		return m.settingsScreen.View()
+	case ScreenDashboard:
+		return m.dashboard.View()
+	case ScreenPresets:
+		return m.presets.list.View()
+	case ScreenSavePreset:
+		return fmt.Sprintf("Save the %s form as a preset\n\n", m.presets.form) + m.presets.saveScreen.View()
+	case ScreenBatchCommand:
+		return fmt.Sprintf("Run a command in the %d selected repositories\n\n", len(m.batchRepos)) + m.commandScreen.View()
 
 	default:
 		return "Unknown Screen"
@@ -410,15 +582,18 @@ func InitialModel(cfg *config.Config) *Model {
 	// Define the option list for the List Screen.
 	oplist := HermesList.Config{
 		IsDir:            false,
-		StaticList:       []string{constant.OptionListPullPr, constant.OptionListAutoMergeReq, constant.OptionListShowProject, constant.OptionListLogs, "Quit"},
+		StaticList:       []string{constant.OptionListPullPr, constant.OptionListAutoMergeReq, constant.OptionListShowProject, constant.OptionListDashboard, constant.OptionListLogs, constant.OptionListSettings, "Quit"},
 		InitialPath:      "./",
 		Title:            "Hermes Options",
 		Width:            30,
-		Height:           14,
+		Height:           16,
 		ShowStatusBar:    false,
 		FilteringEnabled: true,
 	}
 
+	// The forms are referenced by validations and option sources that look at other fields.
+	var pullScreenModel, mergeScreenModel *screen.Model
+
 	// Define the form fields for the Pull Screen.
 	pullFields := []screen.ButtonModel{
 		{
@@ -437,15 +612,39 @@ func InitialModel(cfg *config.Config) *Model {
 			Label:       constant.PullFieldPath,
 			PlaceHolder: "Path to download",
 			Width:       50,
+			Type:        screen.FieldPath,
 			Validate:    func(s string) error { return nil },
 		},
+		{
+			Label:   constant.PullFieldSyncPolicy,
+			Type:    screen.FieldSelect,
+			Options: []string{"", client.SyncPolicyPull, client.SyncPolicyFetch, client.SyncPolicyFastForward},
+		},
+		{
+			Label:       constant.PullFieldOneBranch,
+			PlaceHolder: "only update the branch below",
+			Type:        screen.FieldCheckbox,
+		},
+		{
+			Label:       constant.PullFieldBranch,
+			PlaceHolder: "branch to pull when one branch only is checked",
+			Width:       50,
+			Validate: func(s string) error {
+				if strings.TrimSpace(s) == "" && pullScreenModel.GetValue().Bool(constant.PullFieldOneBranch) {
+					return fmt.Errorf("pull branch cannot be empty")
+				}
+				return nil
+			},
+		},
 	}
 	// Define the form fields for the Pull Screen.
 	mergeRequestFields := []screen.ButtonModel{
 		{
 			Label:       constant.MergeFieldCommand,
-			PlaceHolder: "go mod tidy;go get githubPkg",
+			PlaceHolder: "go mod tidy;go get githubPkg (or one command per line)",
 			Width:       50,
+			Type:        screen.FieldTextArea,
+			Height:      3,
 			Validate:    func(s string) error { return nil },
 		},
 		{
@@ -464,12 +663,25 @@ func InitialModel(cfg *config.Config) *Model {
 			Label:       constant.ContextValueDir,
 			PlaceHolder: "project path",
 			Width:       50,
+			Type:        screen.FieldPath,
 			Validate:    func(s string) error { return nil },
 		},
 		{
 			Label:       constant.MergeFieldMergeRequestTargetBranch,
-			PlaceHolder: "target branch (comma-separated)",
+			PlaceHolder: "target branches",
 			Width:       50,
+			Type:        screen.FieldMultiSelect,
+			// Branches of the repositories in the directory of the form, or in WORKING_DIR until one is entered.
+			LoadOptions: func() ([]string, error) {
+				dir := mergeScreenModel.GetValue().Text(constant.ContextValueDir)
+				if dir == "" {
+					dir = cfg.WorkingDir
+				}
+				if dir == "" {
+					return nil, fmt.Errorf("enter the project path or set WORKING_DIR, then press Ctrl+R")
+				}
+				return client.RemoteBranches(dir)
+			},
 			Validate: func(s string) error {
 				if s == "" {
 					return fmt.Errorf("target branch cannot be empty")
@@ -504,6 +716,8 @@ func InitialModel(cfg *config.Config) *Model {
 			Label:       constant.MergeFieldMergeRequestDescription,
 			PlaceHolder: "description",
 			Width:       50,
+			Type:        screen.FieldTextArea,
+			Height:      4,
 			Validate:    func(s string) error { return nil },
 		},
 	}
@@ -528,8 +742,8 @@ func InitialModel(cfg *config.Config) *Model {
 	fileListLogger.InfoString("starting file list operations...")
 	optionListModel, _ := HermesList.NewModel(oplist, mainLogger)
 	// Initialize the Pull Screen with its logger.
-	pullScreenModel := screen.NewModel(pullFields, pullLogger)
-	mergeScreenModel := screen.NewModel(mergeRequestFields, autoMergeLogger)
+	pullScreenModel = screen.NewModel(pullFields, pullLogger)
+	mergeScreenModel = screen.NewModel(mergeRequestFields, autoMergeLogger)
 
 	return &Model{
 		currentScreen:      ScreenWelcome,
@@ -544,5 +758,6 @@ func InitialModel(cfg *config.Config) *Model {
 		width:              0,
 		height:             0,
 		cfg:                cfg,
+		store:              state.NewStore(cfg.StateDir),
 	}
 }
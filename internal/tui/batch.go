@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sinaw369/Hermes/internal/client"
+	"github.com/sinaw369/Hermes/internal/config"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/form/logsScreen"
+	"github.com/sinaw369/Hermes/internal/form/progressScreen"
+	"github.com/sinaw369/Hermes/internal/form/screen"
+	HermesMsg "github.com/sinaw369/Hermes/internal/message"
+	"maps"
+	"strings"
+	"time"
+)
+
+// batchCommandField is the label of the only field of the batch command form.
+const batchCommandField = "Command"
+
+// diffReportMsg carries a diff report built in the background.
+type diffReportMsg struct {
+	report string
+}
+
+// startBatch starts the action chosen on the Files screen for the selected repositories.
+func (m *Model) startBatch(batch HermesMsg.BatchMsg) (tea.Model, tea.Cmd) {
+	m.LogWriter.InfoString("Batch %s on %d repositories", batch.Action, len(batch.Paths))
+	m.batchRepos = batch.Paths
+	switch batch.Action {
+	case HermesMsg.BatchPull:
+		if err := m.cfg.Require(config.KeyGitlabBaseURL, config.KeyGitlabToken); err != nil {
+			return m.showClientError(err)
+		}
+		return m.runBatch(nil, func(gClient *client.GitlabClient) { gClient.PullRepositoriesTUI() })
+	case HermesMsg.BatchMerge:
+		m.currentScreen = ScreenAutoMergeReq
+		return m, m.autoMergeReqScreen.Init()
+	case HermesMsg.BatchCommand:
+		m.commandScreen = screen.NewModel([]screen.ButtonModel{{
+			Label:       batchCommandField,
+			PlaceHolder: "go mod tidy;go test ./... (or one command per line)",
+			Width:       50,
+			Type:        screen.FieldTextArea,
+			Height:      3,
+			Validate: func(s string) error {
+				if strings.TrimSpace(s) == "" {
+					return fmt.Errorf("command cannot be empty")
+				}
+				return nil
+			},
+		}}, m.LogWriter)
+		m.currentScreen = ScreenBatchCommand
+		return m, m.commandScreen.Init()
+	case HermesMsg.BatchDiff:
+		paths, from, to := batch.Paths, m.cfg.DiffBranchFrom, m.cfg.DifBranchTO
+		status := m.fileList.List.NewStatusMessage(fmt.Sprintf("building the diff report of %d repositories...", len(paths)))
+		return m, tea.Batch(status, func() tea.Msg {
+			return diffReportMsg{report: client.DiffReport(paths, from, to)}
+		})
+	}
+	return m, nil
+}
+
+// updateBatchCommandScreen runs the entered command in the selected repositories once submitted.
+func (m *Model) updateBatchCommandScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.commandScreen.Update(msg)
+	m.commandScreen = updated.(*screen.Model)
+	if !m.commandScreen.Submitted {
+		return m, cmd
+	}
+	command := m.commandScreen.GetValue().Text(batchCommandField)
+	return m.runBatch(nil, func(gClient *client.GitlabClient) { gClient.RunCommandTUI(command) })
+}
+
+// runBatch creates a client for the selected repositories, runs it in the background and shows
+// its progress. The workspace locked during the run is the root of the Files screen.
+func (m *Model) runBatch(values map[string]string, run func(gClient *client.GitlabClient)) (tea.Model, tea.Cmd) {
+	values = maps.Clone(values)
+	if values == nil {
+		values = make(map[string]string)
+	}
+	values[constant.TargetDir] = m.fileList.InitialPath
+	updatesChan := make(chan progressScreen.PackageUpdate)
+	gClient, err := client.NewTUIGitClient(context.Background(), updatesChan, values, m.cfg, m.logsScreen)
+	if err != nil {
+		return m.showClientError(err)
+	}
+	gClient.SetRepositories(m.batchRepos)
+	m.batchRepos = nil
+	go run(gClient)
+
+	m.currentScreen = ScreenProgress
+	m.progressScreen = progressScreen.NewModel(updatesChan, m.LogWriter)
+	return m, m.progressScreen.Init()
+}
+
+// showClientError switches to the client logs after the client could not be created.
+func (m *Model) showClientError(err error) (tea.Model, tea.Cmd) {
+	m.LogWriter.RedString("GitClient Initialization Failed: %v", err)
+	m.batchRepos = nil
+	m.currentScreen = ScreenLogs
+	m.logsScreen.SetActiveTabByName(constant.LGitClient)
+
+	updatedLogsScreen, logCmd := m.logsScreen.Update(HermesMsg.BackMsg{})
+	m.logsScreen = updatedLogsScreen.(*logsScreen.LogModel)
+	return m, logCmd
+}
+
+// showDiffReport adds the report to its logs tab and shows it.
+func (m *Model) showDiffReport(report string) (tea.Model, tea.Cmd) {
+	m.batchRepos = nil
+	m.logsScreen.AddTab(constant.LDiffReport)
+	m.logsScreen.AppendToTab(constant.LDiffReport, fmt.Sprintf("--- %s\n%s\n", time.Now().Format("2006-01-02 15:04:05"), report))
+	m.logsScreen.SetActiveTabByName(constant.LDiffReport)
+	m.currentScreen = ScreenLogs
+
+	updatedLogsScreen, cmd := m.logsScreen.Update(HermesMsg.BackMsg{})
+	m.logsScreen = updatedLogsScreen.(*logsScreen.LogModel)
+	return m, cmd
+}
+
+// batchHeader tells that the merge form runs on the repositories selected on the Files screen.
+func (m *Model) batchHeader() string {
+	if m.batchRepos == nil {
+		return ""
+	}
+	return presetHelpStyle.Render(fmt.Sprintf("Runs on the %d repositories selected on the Files screen; Include, Exclude and Dir Path are not used to select them.", len(m.batchRepos))) + "\n"
+}
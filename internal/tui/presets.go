@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sinaw369/Hermes/internal/constant"
+	"github.com/sinaw369/Hermes/internal/form/screen"
+	HermesList "github.com/sinaw369/Hermes/internal/list"
+	"github.com/sinaw369/Hermes/internal/state"
+	"strings"
+)
+
+// presetNameField is the label of the only field of the save preset form.
+const presetNameField = "Preset name"
+
+var presetHelpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#767676"))
+
+// presetHelp is shown below the forms that support presets.
+var presetHelp = presetHelpStyle.Render("\nPress 'Ctrl+O' to load a preset or a previous submission, 'Ctrl+S' to save the form as a preset.")
+
+// presetState is the state of the preset screens: the form they belong to and the screen to return to.
+type presetState struct {
+	form       string
+	returnTo   Screen
+	list       *HermesList.Model
+	entries    map[string]map[string]string // values by list item
+	saveScreen *screen.Model
+}
+
+// formScreen returns the form model of a preset form.
+func (m *Model) formScreen(form string) *screen.Model {
+	if form == state.FormMerge {
+		return m.autoMergeReqScreen
+	}
+	return m.pullScreen
+}
+
+// handlePresetKeys opens the preset screens from a form; it reports whether the key was handled.
+func (m *Model) handlePresetKeys(msg tea.Msg, form string, from Screen) (bool, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return false, nil
+	}
+	switch key.String() {
+	case "ctrl+o":
+		m.openPresetList(form, from)
+		return true, nil
+	case "ctrl+s":
+		m.presets.form, m.presets.returnTo = form, from
+		m.presets.saveScreen = screen.NewModel([]screen.ButtonModel{{
+			Label:       presetNameField,
+			PlaceHolder: "e.g. bump-go-deps",
+			Width:       50,
+			Validate:    state.ValidPresetName,
+		}}, m.LogWriter)
+		m.currentScreen = ScreenSavePreset
+		return true, m.presets.saveScreen.Init()
+	}
+	return false, nil
+}
+
+// openPresetList lists the presets of the form, followed by its previous submissions.
+func (m *Model) openPresetList(form string, from Screen) {
+	m.presets.form, m.presets.returnTo = form, from
+	m.presets.entries = make(map[string]map[string]string)
+
+	var items []string
+	presets, err := m.store.Presets(form)
+	if err != nil {
+		m.LogWriter.ErrorString("Error reading presets: %v", err)
+	}
+	for _, preset := range presets {
+		item := "Preset: " + preset.Name
+		items = append(items, item)
+		m.presets.entries[item] = preset.Values
+	}
+	history, err := m.store.History(form)
+	if err != nil {
+		m.LogWriter.ErrorString("Error reading form history: %v", err)
+	}
+	for i, entry := range history {
+		item := fmt.Sprintf("History %d: %s  %s", i+1, entry.Saved.Format("2006-01-02 15:04"), historySummary(form, entry.Values))
+		items = append(items, item)
+		m.presets.entries[item] = entry.Values
+	}
+
+	title := "Presets and history of the " + form + " form"
+	if len(items) == 0 {
+		title = "No presets or history yet for the " + form + " form (Esc to go back)"
+	}
+	m.presets.list, _ = HermesList.NewModel(HermesList.Config{
+		StaticList:       items,
+		Title:            title,
+		Width:            m.width,
+		Height:           max(m.height-2, 10),
+		FilteringEnabled: true,
+	}, m.LogWriter)
+	m.currentScreen = ScreenPresets
+}
+
+// historySummary describes a previous submission in one line.
+func historySummary(form string, values map[string]string) string {
+	var parts []string
+	if form == state.FormMerge {
+		parts = append(parts, values[constant.MergeFieldMergeRequestTitle], "-> "+values[constant.MergeFieldMergeRequestTargetBranch])
+	}
+	if include := values[constant.ContextValueInclude]; include != "" {
+		parts = append(parts, "include "+include)
+	}
+	if dir := values[constant.ContextValueDir]; dir != "" {
+		parts = append(parts, "in "+dir)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// updatePresetListScreen loads the chosen preset or submission into its form.
+func (m *Model) updatePresetListScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updatedList, cmd := m.presets.list.Update(msg)
+	m.presets.list = updatedList.(*HermesList.Model)
+	if choice := m.presets.list.Choice; choice != "" {
+		m.presets.list.Choice = ""
+		if values, ok := m.presets.entries[choice]; ok {
+			m.formScreen(m.presets.form).SetValues(values)
+			m.LogWriter.InfoString("Loaded %s into the %s form", choice, m.presets.form)
+		}
+		m.currentScreen = m.presets.returnTo
+		return m, nil
+	}
+	return m, cmd
+}
+
+// updateSavePresetScreen saves the form's current values under the entered name.
+func (m *Model) updateSavePresetScreen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.presets.saveScreen.Update(msg)
+	m.presets.saveScreen = updated.(*screen.Model)
+	if !m.presets.saveScreen.Submitted {
+		return m, cmd
+	}
+
+	name := m.presets.saveScreen.GetValue().Text(presetNameField)
+	form := m.formScreen(m.presets.form)
+	if err := m.store.SavePreset(m.presets.form, name, form.GetValue().Strings()); err != nil {
+		m.presets.saveScreen.Submitted = false
+		m.presets.saveScreen.Err = err
+		return m, nil
+	}
+	m.LogWriter.GreenString("Saved the %s form as preset %q", m.presets.form, name)
+	form.Err = nil
+	m.currentScreen = m.presets.returnTo
+	return m, nil
+}
+
+// recordHistory adds a submission of a form to its history.
+func (m *Model) recordHistory(form string, values map[string]string) {
+	if m.cfg.FormHistory == 0 {
+		return
+	}
+	if err := m.store.AddHistory(form, values, m.cfg.FormHistory); err != nil {
+		m.LogWriter.ErrorString("Error saving form history: %v", err)
+	}
+}